@@ -6,7 +6,9 @@ replace k8s.io/client-go => k8s.io/client-go v0.18.0
 
 require (
 	github.com/ehazlett/simplelog v0.0.0-20200226020431-d374894e92a4
+	github.com/evanphx/json-patch v4.5.0+incompatible
 	github.com/minio/minio-go/v6 v6.0.57
+	github.com/prometheus/client_golang v1.0.0
 	github.com/prometheus/common v0.4.1
 	github.com/rancher/lasso v0.0.0-20200515155337-a34e1e26ad91
 	github.com/rancher/wrangler v0.6.2-0.20200622171942-7224e49a2407
@@ -21,4 +23,5 @@ require (
 	k8s.io/apiserver v0.18.0
 	k8s.io/client-go v0.18.0
 	k8s.io/utils v0.0.0-20200324210504-a9aa75ae1b89
+	sigs.k8s.io/yaml v1.2.0
 )
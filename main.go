@@ -10,11 +10,15 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	"github.com/rancher/backup-restore-operator/pkg/controllers/backup"
+	"github.com/rancher/backup-restore-operator/pkg/controllers/namespaced"
 	"github.com/rancher/backup-restore-operator/pkg/controllers/restore"
 	"github.com/rancher/backup-restore-operator/pkg/generated/controllers/resources.cattle.io"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
 	"github.com/rancher/backup-restore-operator/pkg/util"
 	lasso "github.com/rancher/lasso/pkg/client"
 	"github.com/rancher/lasso/pkg/mapper"
@@ -26,12 +30,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
 const (
-	LogFormat = "2006/01/02 15:04:05"
+	LogFormat               = "2006/01/02 15:04:05"
+	LeaderElectionLeaseName = "backup-restore-operator"
 )
 
 var (
@@ -39,17 +45,25 @@ var (
 	GitCommit                       = "HEAD"
 	LocalBackupStorageLocation      = "/var/lib/backups" // local within the pod, this is the mountPath for PVC
 	KubeConfig                      string
+	MetricsAddress                  string
 	OperatorPVEnabled               string
 	OperatorS3BackupStorageLocation string
 	ChartNamespace                  string
+	OrphanedBackupCleanupInterval   string
+	ClusterName                     string
+	RestoreResourcePriority         string
 )
 
 func init() {
 	flag.StringVar(&KubeConfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&MetricsAddress, "metrics-address", ":8080", "Address to serve Prometheus metrics on. Set to empty string to disable.")
 	flag.Parse()
 	OperatorPVEnabled = os.Getenv("DEFAULT_PERSISTENCE_ENABLED")
 	OperatorS3BackupStorageLocation = os.Getenv("DEFAULT_S3_BACKUP_STORAGE_LOCATION")
 	ChartNamespace = os.Getenv("CHART_NAMESPACE")
+	OrphanedBackupCleanupInterval = os.Getenv("ORPHANED_BACKUP_CLEANUP_INTERVAL")
+	ClusterName = os.Getenv("CLUSTER_NAME")
+	RestoreResourcePriority = os.Getenv("RESTORE_RESOURCE_PRIORITY")
 }
 
 func main() {
@@ -141,22 +155,63 @@ func main() {
 		}
 	}
 
+	var orphanedBackupCleanupInterval time.Duration
+	if OrphanedBackupCleanupInterval != "" {
+		orphanedBackupCleanupInterval, err = time.ParseDuration(OrphanedBackupCleanupInterval)
+		if err != nil {
+			logrus.Fatalf("Error parsing orphaned backup cleanup interval %v: %v", OrphanedBackupCleanupInterval, err)
+		}
+	}
+
+	var defaultResourcePriority []schema.GroupResource
+	if RestoreResourcePriority != "" {
+		defaultResourcePriority, err = restore.ParseResourcePriority(strings.Split(RestoreResourcePriority, ","))
+		if err != nil {
+			logrus.Fatalf("Error parsing restore resource priority %v: %v", RestoreResourcePriority, err)
+		}
+	}
+
+	recorder, err := util.NewEventRecorder(k8sclient)
+	if err != nil {
+		logrus.Fatalf("Error creating event recorder: %s", err.Error())
+	}
+
 	util.ChartNamespace = ChartNamespace
+	util.ClusterName = ClusterName
+	util.OperatorVersion = Version
 	logrus.Infof("Secrets containing encryption config files must be stored in the namespace %v", ChartNamespace)
 
-	backup.Register(ctx, backups.Resources().V1().Backup(),
-		backups.Resources().V1().ResourceSet(),
-		core.Core().V1().Secret(),
-		core.Core().V1().Namespace(),
-		clientSet, dynamicInterace, defaultMountPath, defaultS3)
-	restore.Register(ctx, backups.Resources().V1().Restore(),
-		backups.Resources().V1().Backup(),
-		core.Core().V1().Secret(),
-		k8sclient.CoordinationV1().Leases(ChartNamespace),
-		clientSet, dynamicInterace, sharedClientFactory, restmapper, defaultMountPath, defaultS3)
-
-	if err := start.All(ctx, 2, backups); err != nil {
-		logrus.Fatalf("Error starting: %s", err.Error())
+	if MetricsAddress != "" {
+		go metrics.Serve(MetricsAddress)
+	}
+
+	// only the replica holding the leader election lease runs the controllers, the others wait on hot-standby to take over
+	err = util.RunAsLeader(ctx, k8sclient, LeaderElectionLeaseName, func(ctx context.Context) {
+		backup.Register(ctx, backups.Resources().V1().Backup(),
+			backups.Resources().V1().BackupRun(),
+			backups.Resources().V1().ResourceSet(),
+			core.Core().V1().Secret(),
+			core.Core().V1().Namespace(),
+			clientSet, dynamicInterace, restKubeConfig, recorder, defaultMountPath, defaultS3, orphanedBackupCleanupInterval)
+		restore.Register(ctx, backups.Resources().V1().Restore(),
+			backups.Resources().V1().Backup(),
+			core.Core().V1().Secret(),
+			core.Core().V1().ConfigMap(),
+			k8sclient.CoordinationV1().Leases(ChartNamespace),
+			clientSet, dynamicInterace, restKubeConfig, sharedClientFactory, restmapper, recorder, defaultMountPath, defaultS3,
+			defaultResourcePriority)
+		namespaced.Register(ctx, backups.Resources().V1().NamespacedBackup(),
+			backups.Resources().V1().NamespacedRestore(),
+			backups.Resources().V1().Backup(),
+			backups.Resources().V1().ResourceSet(),
+			backups.Resources().V1().Restore())
+
+		if err := start.All(ctx, 2, backups); err != nil {
+			logrus.Fatalf("Error starting: %s", err.Error())
+		}
+	})
+	if err != nil {
+		logrus.Fatalf("Error running leader election: %s", err.Error())
 	}
 
 	<-ctx.Done()
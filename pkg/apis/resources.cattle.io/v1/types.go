@@ -10,9 +10,33 @@ var (
 	BackupConditionUploaded     = "Uploaded"
 	BackupConditionReconciling  = "Reconciling"
 	BackupConditionStalled      = "Stalled"
+	BackupConditionVerified     = "Verified"
 	RestoreConditionReconciling = "Reconciling"
 	RestoreConditionStalled     = "Stalled"
 	RestoreConditionReady       = "Ready"
+
+	BackupResultSucceeded = "Succeeded"
+	BackupResultFailed    = "Failed"
+
+	DeletionPolicyRetain = "Retain"
+	DeletionPolicyDelete = "Delete"
+
+	BackupRunPhaseRunning   = "Running"
+	BackupRunPhaseSucceeded = "Succeeded"
+	BackupRunPhaseFailed    = "Failed"
+	BackupRunPhaseStalled   = "Stalled"
+
+	BackupRunTriggerScheduled = "Scheduled"
+	BackupRunTriggerOneTime   = "OneTime"
+	BackupRunTriggerManual    = "Manual"
+
+	ConflictPolicyOverwrite                 = "Overwrite"
+	ConflictPolicySkipIfExists              = "SkipIfExists"
+	ConflictPolicyFailIfExists              = "FailIfExists"
+	ConflictPolicySkipIfModifiedAfterBackup = "SkipIfModifiedAfterBackup"
+
+	ApplyModeUpdate          = "Update"
+	ApplyModeServerSideApply = "ServerSideApply"
 )
 
 // +genclient
@@ -33,17 +57,168 @@ type BackupSpec struct {
 	EncryptionConfigSecretName string           `json:"encryptionConfigSecretName,omitempty"`
 	Schedule                   string           `json:"schedule,omitempty"`
 	RetentionCount             int64            `json:"retentionCount,omitempty"`
+	RetentionPolicy            *RetentionPolicy `json:"retentionPolicy,omitempty"`
+	Quiesce                    bool             `json:"quiesce,omitempty"`
+	DeletionPolicy             string           `json:"deletionPolicy,omitempty"`
+	Suspend                    bool             `json:"suspend,omitempty"`
+	StartingDeadlineSeconds    *int64           `json:"startingDeadlineSeconds,omitempty"`
+	TimeZone                   string           `json:"timeZone,omitempty"`
+	JitterWindowSeconds        int64            `json:"jitterWindowSeconds,omitempty"`
+	TimeoutSeconds             int64            `json:"timeoutSeconds,omitempty"`
+	SuccessfulRunsHistoryLimit *int32           `json:"successfulRunsHistoryLimit,omitempty"`
+	FailedRunsHistoryLimit     *int32           `json:"failedRunsHistoryLimit,omitempty"`
+	FilenameTemplate           string           `json:"filenameTemplate,omitempty"`
+	RestrictToNamespace        string           `json:"restrictToNamespace,omitempty"`
+	ServiceAccountName         string           `json:"serviceAccountName,omitempty"`
+	VerifyAfterUpload          bool             `json:"verifyAfterUpload,omitempty"`
+	ScrubSchedule              string           `json:"scrubSchedule,omitempty"`
+	StorageTagLabels           []string         `json:"storageTagLabels,omitempty"`
+}
+
+// RetentionPolicy keeps every backup matched by at least one of its rules, and deletes the rest.
+// The age of a backup is computed from the timestamp in its filename
+type RetentionPolicy struct {
+	KeepLast    int               `json:"keepLast,omitempty"`
+	MaxAgeDays  int               `json:"maxAgeDays,omitempty"`
+	KeepDaily   int               `json:"keepDaily,omitempty"`
+	KeepWeekly  int               `json:"keepWeekly,omitempty"`
+	KeepMonthly int               `json:"keepMonthly,omitempty"`
+	MinKeep     int               `json:"minKeep,omitempty"`
+	DryRun      bool              `json:"dryRun,omitempty"`
+	TagSelector map[string]string `json:"tagSelector,omitempty"`
 }
 
 type BackupStatus struct {
-	Conditions         []genericcondition.GenericCondition `json:"conditions"`
-	LastSnapshotTS     string                              `json:"lastSnapshotTs"`
-	NextSnapshotAt     string                              `json:"nextSnapshotAt"`
-	ObservedGeneration int64                               `json:"observedGeneration"`
-	StorageLocation    string                              `json:"storageLocation"`
-	BackupType         string                              `json:"backupType"`
-	Filename           string                              `json:"filename"`
-	Summary            string                              `json:"summary"`
+	Conditions          []genericcondition.GenericCondition `json:"conditions"`
+	LastSnapshotTS      string                              `json:"lastSnapshotTs"`
+	NextSnapshotAt      string                              `json:"nextSnapshotAt"`
+	ObservedGeneration  int64                               `json:"observedGeneration"`
+	StorageLocation     string                              `json:"storageLocation"`
+	BackupType          string                              `json:"backupType"`
+	Filename            string                              `json:"filename"`
+	Summary             string                              `json:"summary"`
+	QuiescedControllers []ControllerReference               `json:"quiescedControllers,omitempty"`
+	History             []BackupHistoryEntry                `json:"history,omitempty"`
+	RetentionDryRun     []string                            `json:"retentionDryRun,omitempty"`
+	LastTrigger         string                              `json:"lastTrigger,omitempty"`
+	MissedSnapshotAt    string                              `json:"missedSnapshotAt,omitempty"`
+	ScheduleTimeZone    string                              `json:"scheduleTimeZone,omitempty"`
+	ScheduleJitter      string                              `json:"scheduleJitter,omitempty"`
+	LastSuccessfulRun   string                              `json:"lastSuccessfulRun,omitempty"`
+	LastSuccess         *BackupHistoryEntry                 `json:"lastSuccess,omitempty"`
+	LastVerification    *BackupVerification                 `json:"lastVerification,omitempty"`
+	Scrub               *BackupScrubStatus                  `json:"scrub,omitempty"`
+}
+
+// BackupVerification is the result of reading a backup file back from its storage location the way a restore would,
+// decompressing, decrypting and parsing every entry
+type BackupVerification struct {
+	Filename   string `json:"filename"`
+	VerifiedAt string `json:"verifiedAt"`
+	Result     string `json:"result"`
+	Objects    int    `json:"objects,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type BackupScrubStatus struct {
+	LastScrubAt   string               `json:"lastScrubAt,omitempty"`
+	NextScrubAt   string               `json:"nextScrubAt,omitempty"`
+	VerifiedFiles int                  `json:"verifiedFiles"`
+	FailedFiles   []BackupVerification `json:"failedFiles,omitempty"`
+	Error         string               `json:"error,omitempty"`
+}
+
+type BackupHistoryEntry struct {
+	Filename        string         `json:"filename"`
+	StorageLocation string         `json:"storageLocation"`
+	SizeBytes       int64          `json:"sizeBytes"`
+	ObjectCounts    map[string]int `json:"objectCounts,omitempty"`
+	StartTime       string         `json:"startTime"`
+	Duration        string         `json:"duration"`
+	Result          string         `json:"result"`
+	Error           string         `json:"error,omitempty"`
+	// Run identifies the execution recorded: the scheduled time, the trigger, or the generation of a one-time backup. The
+	// failed attempts of a run that are retried share its entry
+	Run      string `json:"run,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupRun records a single execution of a Backup, which owns it
+type BackupRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupRunSpec   `json:"spec"`
+	Status BackupRunStatus `json:"status"`
+}
+
+type BackupRunSpec struct {
+	BackupName string `json:"backupName"`
+	Trigger    string `json:"trigger"`
+}
+
+type BackupRunStatus struct {
+	Phase           string         `json:"phase"`
+	StartTime       string         `json:"startTime"`
+	CompletionTime  string         `json:"completionTime,omitempty"`
+	Duration        string         `json:"duration,omitempty"`
+	StorageLocation string         `json:"storageLocation,omitempty"`
+	Filename        string         `json:"filename,omitempty"`
+	SizeBytes       int64          `json:"sizeBytes,omitempty"`
+	ObjectCounts    map[string]int `json:"objectCounts,omitempty"`
+	Error           string         `json:"error,omitempty"`
+	Logs            []string       `json:"logs,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespacedBackup backs up resources of its own namespace only, so it can be created by tenants without access to the
+// cluster-scoped Backup. It is carried out by a cluster-scoped Backup restricted to its namespace
+type NamespacedBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespacedBackupSpec `json:"spec"`
+	Status BackupStatus         `json:"status"`
+}
+
+type NamespacedBackupSpec struct {
+	ResourceSelectors          []ResourceSelector `json:"resourceSelectors"`
+	StorageLocation            *StorageLocation   `json:"storageLocation"`
+	EncryptionConfigSecretName string             `json:"encryptionConfigSecretName,omitempty"`
+	Schedule                   string             `json:"schedule,omitempty"`
+	RetentionCount             int64              `json:"retentionCount,omitempty"`
+	Suspend                    bool               `json:"suspend,omitempty"`
+	ServiceAccountName         string             `json:"serviceAccountName,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespacedRestore restores resources of its own namespace only, from a backup file taken by a NamespacedBackup or a Backup.
+// It is carried out by a cluster-scoped Restore restricted to its namespace
+type NamespacedRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespacedRestoreSpec `json:"spec"`
+	Status RestoreStatus         `json:"status"`
+}
+
+type NamespacedRestoreSpec struct {
+	BackupFilename             string            `json:"backupFilename"`
+	StorageLocation            *StorageLocation  `json:"storageLocation"`
+	Prune                      *bool             `json:"prune"`
+	DeleteTimeoutSeconds       int               `json:"deleteTimeoutSeconds,omitempty"`
+	EncryptionConfigSecretName string            `json:"encryptionConfigSecretName,omitempty"`
+	ServiceAccountName         string            `json:"serviceAccountName,omitempty"`
+	BackupTagSelector          map[string]string `json:"backupTagSelector,omitempty"`
+	DryRun                     bool              `json:"dryRun,omitempty"`
 }
 
 // +genclient
@@ -75,7 +250,7 @@ type ControllerReference struct {
 	Resource   string `json:"resource"`
 	Namespace  string `json:"namespace"`
 	Name       string `json:"name"`
-	Replicas   int32
+	Replicas   int32  `json:"replicas"`
 }
 
 type StorageLocation struct {
@@ -106,11 +281,57 @@ type Restore struct {
 }
 
 type RestoreSpec struct {
-	BackupFilename             string           `json:"backupFilename"`
-	StorageLocation            *StorageLocation `json:"storageLocation"`
-	Prune                      *bool            `json:"prune"` //prune by default
-	DeleteTimeoutSeconds       int              `json:"deleteTimeoutSeconds,omitempty"`
-	EncryptionConfigSecretName string           `json:"encryptionConfigSecretName,omitempty"`
+	BackupFilename             string                   `json:"backupFilename"`
+	StorageLocation            *StorageLocation         `json:"storageLocation"`
+	Prune                      *bool                    `json:"prune"` //prune by default
+	DeleteTimeoutSeconds       int                      `json:"deleteTimeoutSeconds,omitempty"`
+	EncryptionConfigSecretName string                   `json:"encryptionConfigSecretName,omitempty"`
+	RestrictToNamespace        string                   `json:"restrictToNamespace,omitempty"`
+	ServiceAccountName         string                   `json:"serviceAccountName,omitempty"`
+	BackupTagSelector          map[string]string        `json:"backupTagSelector,omitempty"`
+	DryRun                     bool                     `json:"dryRun,omitempty"`
+	IncludeResourceSelectors   []ResourceSelector       `json:"includeResourceSelectors,omitempty"`
+	ExcludeResourceSelectors   []ResourceSelector       `json:"excludeResourceSelectors,omitempty"`
+	NamespaceMapping           map[string]string        `json:"namespaceMapping,omitempty"`
+	Transformations            []TransformationRule     `json:"transformations,omitempty"`
+	ConflictPolicy             string                   `json:"conflictPolicy,omitempty"`
+	ResourceConflictPolicies   []ResourceConflictPolicy `json:"resourceConflictPolicies,omitempty"`
+	ApplyMode                  string                   `json:"applyMode,omitempty"`
+	ForceConflicts             bool                     `json:"forceConflicts,omitempty"`
+	RecreateKinds              []RecreateKind           `json:"recreateKinds,omitempty"`
+	Concurrency                int                      `json:"concurrency,omitempty"`
+	ResourcePriority           []string                 `json:"resourcePriority,omitempty"`
+}
+
+// RecreateKind allows the objects of a kind to be deleted and created again by a Restore when updating them fails because their
+// immutable fields differ from the backup
+type RecreateKind struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// ResourceConflictPolicy overrides the Restore's conflictPolicy for the objects of one resource
+type ResourceConflictPolicy struct {
+	APIVersion string `json:"apiVersion"`
+	Resource   string `json:"resource"`
+	Policy     string `json:"policy"`
+}
+
+// TransformationRule changes the objects matching its resourceSelector before they are restored. The JSON patch, the strategic
+// merge patch and the field replacements are applied in that order, any of them can be left out
+type TransformationRule struct {
+	Name                string             `json:"name"`
+	ResourceSelector    ResourceSelector   `json:"resourceSelector"`
+	JSONPatch           string             `json:"jsonPatch,omitempty"`
+	StrategicMergePatch string             `json:"strategicMergePatch,omitempty"`
+	FieldReplacements   []FieldReplacement `json:"fieldReplacements,omitempty"`
+}
+
+// FieldReplacement replaces the string values at a path of the object, or only the parts of them matching the regexp
+type FieldReplacement struct {
+	Path   string `json:"path"`
+	Regexp string `json:"regexp,omitempty"`
+	Value  string `json:"value"`
 }
 
 type RestoreStatus struct {
@@ -119,4 +340,30 @@ type RestoreStatus struct {
 	ObservedGeneration  int64                               `json:"observedGeneration"`
 	BackupSource        string                              `json:"backupSource"`
 	Summary             string                              `json:"summary"`
+	DryRunPlan          *RestorePlanSummary                 `json:"dryRunPlan,omitempty"`
+	Report              *RestoreReportSummary               `json:"report,omitempty"`
+	// BackupFilename is the backup file the backupTagSelector selected, retries restore from it even if newer files match since
+	BackupFilename string `json:"backupFilename,omitempty"`
+}
+
+// RestoreReportSummary counts what a Restore recorded in its report, the full report listing every object is stored in the ConfigMap
+type RestoreReportSummary struct {
+	Transformations int    `json:"transformations"`
+	Overwritten     int    `json:"overwritten"`
+	Skipped         int    `json:"skipped"`
+	Failed          int    `json:"failed"`
+	Recreated       int    `json:"recreated"`
+	ConfigMap       string `json:"configMap,omitempty"`
+}
+
+// RestorePlanSummary counts the changes a dry-run Restore found it would make, the full plan with the changed fields of every
+// object is stored in the ConfigMap
+type RestorePlanSummary struct {
+	Create    int    `json:"create"`
+	Update    int    `json:"update"`
+	Unchanged int    `json:"unchanged"`
+	Prune     int    `json:"prune"`
+	Skip      int    `json:"skip"`
+	Fail      int    `json:"fail"`
+	ConfigMap string `json:"configMap,omitempty"`
 }
@@ -43,6 +43,57 @@ func NewBackup(namespace, name string, obj Backup) *Backup {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// BackupRunList is a list of BackupRun resources
+type BackupRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []BackupRun `json:"items"`
+}
+
+func NewBackupRun(namespace, name string, obj BackupRun) *BackupRun {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("BackupRun").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespacedBackupList is a list of NamespacedBackup resources
+type NamespacedBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NamespacedBackup `json:"items"`
+}
+
+func NewNamespacedBackup(namespace, name string, obj NamespacedBackup) *NamespacedBackup {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NamespacedBackup").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespacedRestoreList is a list of NamespacedRestore resources
+type NamespacedRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NamespacedRestore `json:"items"`
+}
+
+func NewNamespacedRestore(namespace, name string, obj NamespacedRestore) *NamespacedRestore {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NamespacedRestore").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // ResourceSetList is a list of ResourceSet resources
 type ResourceSetList struct {
 	metav1.TypeMeta `json:",inline"`
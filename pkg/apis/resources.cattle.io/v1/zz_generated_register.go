@@ -28,9 +28,12 @@ import (
 )
 
 var (
-	BackupResourceName      = "backups"
-	ResourceSetResourceName = "resourcesets"
-	RestoreResourceName     = "restores"
+	BackupResourceName            = "backups"
+	BackupRunResourceName         = "backupruns"
+	NamespacedBackupResourceName  = "namespacedbackups"
+	NamespacedRestoreResourceName = "namespacedrestores"
+	ResourceSetResourceName       = "resourcesets"
+	RestoreResourceName           = "restores"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -56,6 +59,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Backup{},
 		&BackupList{},
+		&BackupRun{},
+		&BackupRunList{},
+		&NamespacedBackup{},
+		&NamespacedBackupList{},
+		&NamespacedRestore{},
+		&NamespacedRestoreList{},
 		&ResourceSet{},
 		&ResourceSetList{},
 		&Restore{},
@@ -18,6 +18,9 @@ func main() {
 			"resources.cattle.io": {
 				Types: []interface{}{
 					v1.Backup{},
+					v1.BackupRun{},
+					v1.NamespacedBackup{},
+					v1.NamespacedRestore{},
 					v1.ResourceSet{},
 					v1.Restore{},
 				},
@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	backupRunBackupNameLabel = "resources.cattle.io/backup-name"
+
+	defaultSuccessfulRunsHistoryLimit = 3
+	defaultFailedRunsHistoryLimit     = 1
+)
+
+// createBackupRun creates the BackupRun for the execution of the Backup that just started. It is owned by the Backup,
+// so it's garbage collected along with it
+func (h *handler) createBackupRun(backup *v1.Backup, run *backupRun, trigger string) error {
+	runTrigger := v1.BackupRunTriggerOneTime
+	if trigger != "" {
+		runTrigger = v1.BackupRunTriggerManual
+	} else if backup.Spec.Schedule != "" {
+		runTrigger = v1.BackupRunTriggerScheduled
+	}
+	isController := true
+	backupRunObj := &v1.BackupRun{
+		ObjectMeta: k8sv1.ObjectMeta{
+			Name:   fmt.Sprintf("%s-%d", backup.Name, run.startTime.Unix()),
+			Labels: map[string]string{backupRunBackupNameLabel: backup.Name},
+			OwnerReferences: []k8sv1.OwnerReference{
+				{
+					APIVersion: v1.SchemeGroupVersion.String(),
+					Kind:       "Backup",
+					Name:       backup.Name,
+					UID:        backup.UID,
+					Controller: &isController,
+				},
+			},
+		},
+		Spec: v1.BackupRunSpec{
+			BackupName: backup.Name,
+			Trigger:    runTrigger,
+		},
+	}
+	created, err := h.backupRuns.Create(backupRunObj)
+	if err != nil {
+		return fmt.Errorf("error creating BackupRun for backup CR %v: %v", backup.Name, err)
+	}
+	created.Status.Phase = v1.BackupRunPhaseRunning
+	created.Status.StartTime = run.startTime.Format(time.RFC3339)
+	if created, err = h.backupRuns.UpdateStatus(created); err != nil {
+		return fmt.Errorf("error updating status of BackupRun for backup CR %v: %v", backup.Name, err)
+	}
+	run.name = created.Name
+	return nil
+}
+
+// completeBackupRun records the outcome of the execution on its BackupRun, and deletes the BackupRuns beyond the Backup's history limits.
+// Failing to do so doesn't fail the backup, the BackupRuns only report on it
+func (h *handler) completeBackupRun(backup *v1.Backup, run *backupRun, phase string, runErr error) {
+	if run.name == "" {
+		return
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		backupRunObj, err := h.backupRuns.Get(run.name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		backupRunObj.Status.Phase = phase
+		backupRunObj.Status.CompletionTime = now.Format(time.RFC3339)
+		backupRunObj.Status.Duration = now.Sub(run.startTime).Round(time.Millisecond).String()
+		backupRunObj.Status.StorageLocation = backup.Status.StorageLocation
+		backupRunObj.Status.Filename = run.filename
+		backupRunObj.Status.SizeBytes = run.size
+		backupRunObj.Status.ObjectCounts = run.objectCounts
+		backupRunObj.Status.Logs = run.logs
+		if runErr != nil {
+			backupRunObj.Status.Error = runErr.Error()
+		}
+		_, err = h.backupRuns.UpdateStatus(backupRunObj)
+		return err
+	})
+	if err != nil {
+		logrus.Errorf("Error updating BackupRun %v for backup CR %v: %v", run.name, backup.Name, err)
+	}
+	if err := h.deleteBackupRunsOverHistoryLimit(backup); err != nil {
+		logrus.Errorf("Error deleting BackupRuns over the history limit of backup CR %v: %v", backup.Name, err)
+	}
+}
+
+// deleteBackupRunsOverHistoryLimit keeps the newest successfulRunsHistoryLimit successful, and failedRunsHistoryLimit failed or stalled BackupRuns
+func (h *handler) deleteBackupRunsOverHistoryLimit(backup *v1.Backup) error {
+	backupRuns, err := h.backupRuns.List(k8sv1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", backupRunBackupNameLabel, backup.Name)})
+	if err != nil {
+		return err
+	}
+	sort.Slice(backupRuns.Items, func(i, j int) bool {
+		return backupRuns.Items[j].CreationTimestamp.Before(&backupRuns.Items[i].CreationTimestamp)
+	})
+	successfulLimit := int32(defaultSuccessfulRunsHistoryLimit)
+	if backup.Spec.SuccessfulRunsHistoryLimit != nil {
+		successfulLimit = *backup.Spec.SuccessfulRunsHistoryLimit
+	}
+	failedLimit := int32(defaultFailedRunsHistoryLimit)
+	if backup.Spec.FailedRunsHistoryLimit != nil {
+		failedLimit = *backup.Spec.FailedRunsHistoryLimit
+	}
+	var successful, failed int32
+	for _, backupRunObj := range backupRuns.Items {
+		switch backupRunObj.Status.Phase {
+		case v1.BackupRunPhaseSucceeded:
+			successful++
+			if successful <= successfulLimit {
+				continue
+			}
+		case v1.BackupRunPhaseFailed, v1.BackupRunPhaseStalled:
+			failed++
+			if failed <= failedLimit {
+				continue
+			}
+		default:
+			// runs in progress are never deleted
+			continue
+		}
+		logrus.Infof("Deleting BackupRun %v of backup CR %v to follow its history limit", backupRunObj.Name, backup.Name)
+		if err := h.backupRuns.Delete(backupRunObj.Name, &k8sv1.DeleteOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/genericcondition"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// CancelAnnotation cancels the backup in progress when its value is changed, for example to the current timestamp
+const CancelAnnotation = "resources.cattle.io/cancel"
+
+const cancelCheckInterval = 5 * time.Second
+
+// backupContext returns the context for gathering and uploading a backup, which is done after the Backup's timeoutSeconds,
+// or when its cancel annotation is changed while the backup is in progress
+func (h *handler) backupContext(backup *v1.Backup) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if backup.Spec.TimeoutSeconds > 0 {
+		ctx, cancel = context.WithTimeout(h.ctx, time.Duration(backup.Spec.TimeoutSeconds)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(h.ctx)
+	}
+	go h.cancelOnAnnotationChange(ctx, cancel, backup.Name, backup.Annotations[CancelAnnotation])
+	return ctx, cancel
+}
+
+// cancelOnAnnotationChange polls the cache, because changes to the Backup are not processed by the controller while its backup is in progress
+func (h *handler) cancelOnAnnotationChange(ctx context.Context, cancel context.CancelFunc, backupName, initialValue string) {
+	ticker := time.NewTicker(cancelCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			backup, err := h.backups.Cache().Get(backupName)
+			if err != nil {
+				continue
+			}
+			if value := backup.Annotations[CancelAnnotation]; value != "" && value != initialValue {
+				logrus.Infof("Cancelling backup for backup CR %v, annotation %v changed to %v", backupName, CancelAnnotation, value)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// stallReason returns the reason and message for a backup that was stopped by its context, or empty strings if it wasn't
+func (h *handler) stallReason(ctx context.Context, backup *v1.Backup) (string, string) {
+	if h.ctx.Err() != nil {
+		// the operator is shutting down, the backup is retried once it's back
+		return "", ""
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return "Timeout", fmt.Sprintf("Backup timed out after %vs", backup.Spec.TimeoutSeconds)
+	case context.Canceled:
+		return "Cancelled", fmt.Sprintf("Backup was cancelled with annotation %v", CancelAnnotation)
+	}
+	return "", ""
+}
+
+// setStalledCondition marks the Backup as Stalled instead of retrying the backup. A recurring backup is taken again at its next
+// scheduled time, a one-time backup only if the Backup's spec is updated or a backup is triggered
+func (h *handler) setStalledCondition(backup *v1.Backup, trigger, reason, message string) (*v1.Backup, error) {
+	logrus.Infof("%v for backup CR %v", message, backup.Name)
+	h.recorder.Event(backup, corev1.EventTypeWarning, eventReasonFailed, message)
+	var nextBackupAt time.Time
+	if backup.Spec.Schedule != "" {
+		var err error
+		if nextBackupAt, err = h.nextSnapshotTime(backup, time.Now()); err != nil {
+			return h.setReconcilingCondition(backup, err)
+		}
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updBackup, err := h.backups.Get(backup.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updBackup.Status.Conditions = []genericcondition.GenericCondition{}
+		condition.Cond(v1.BackupConditionStalled).SetStatusBool(updBackup, true)
+		condition.Cond(v1.BackupConditionStalled).Reason(updBackup, reason)
+		condition.Cond(v1.BackupConditionStalled).Message(updBackup, message)
+		condition.Cond(v1.BackupConditionReady).SetStatusBool(updBackup, false)
+		condition.Cond(v1.BackupConditionReady).Message(updBackup, reason)
+		if backup.Spec.Schedule != "" {
+			updBackup.Status.NextSnapshotAt = nextBackupAt.Format(time.RFC3339)
+			h.setScheduleStatus(updBackup)
+			h.backups.EnqueueAfter(backup.Name, nextBackupAt.Sub(time.Now()))
+		}
+		if trigger != "" {
+			updBackup.Status.LastTrigger = trigger
+		}
+		updBackup.Status.ObservedGeneration = updBackup.Generation
+		updBackup, err = h.backups.UpdateStatus(updBackup)
+		if err == nil {
+			backup = updBackup
+		}
+		return err
+	})
+	return backup, err
+}
+
+// isStalled returns true for a Backup that stalled, and wasn't updated since
+func isStalled(backup *v1.Backup) bool {
+	return backup.Generation == backup.Status.ObservedGeneration && condition.Cond(v1.BackupConditionStalled).IsTrue(backup)
+}
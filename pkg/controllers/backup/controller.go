@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	backupControllers "github.com/rancher/backup-restore-operator/pkg/generated/controllers/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
 	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
 	"github.com/rancher/backup-restore-operator/pkg/util"
 	v1core "github.com/rancher/wrangler-api/pkg/generated/controllers/core/v1"
@@ -21,49 +24,81 @@ import (
 	"github.com/robfig/cron"
 	"github.com/sirupsen/logrus"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/storage/value"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 )
 
 type handler struct {
 	ctx                     context.Context
 	backups                 backupControllers.BackupController
+	backupRuns              backupControllers.BackupRunController
 	resourceSets            backupControllers.ResourceSetController
 	secrets                 v1core.SecretController
 	namespaces              v1core.NamespaceController
 	discoveryClient         discovery.DiscoveryInterface
 	dynamicClient           dynamic.Interface
+	storageDynamicClient    dynamic.Interface
+	restConfig              *rest.Config
 	defaultBackupMountPath  string
 	defaultS3BackupLocation *v1.S3ObjectStore
 	kubeSystemNS            string
+	recorder                record.EventRecorder
 }
 
 const DefaultRetentionCount = 10
 
+// Reasons for the Events recorded on Backup CRs
+const (
+	eventReasonStarted            = "BackupStarted"
+	eventReasonGathered           = "GatheringCompleted"
+	eventReasonUploaded           = "UploadCompleted"
+	eventReasonCompleted          = "BackupCompleted"
+	eventReasonFailed             = "BackupFailed"
+	eventReasonRetentionDeleted   = "RetentionDeleted"
+	eventReasonRetentionDryRun    = "RetentionDryRun"
+	eventReasonScaledDown         = "ControllerScaledDown"
+	eventReasonScaledUp           = "ControllerScaledUp"
+	eventReasonFileDeleted        = "BackupFileDeleted"
+	eventReasonMissed             = "BackupMissed"
+	eventReasonVerified           = "BackupVerified"
+	eventReasonVerificationFailed = "VerificationFailed"
+)
+
 func Register(
 	ctx context.Context,
 	backups backupControllers.BackupController,
+	backupRuns backupControllers.BackupRunController,
 	resourceSets backupControllers.ResourceSetController,
 	secrets v1core.SecretController,
 	namespaces v1core.NamespaceController,
 	clientSet *clientset.Clientset,
 	dynamicInterface dynamic.Interface,
+	restConfig *rest.Config,
+	recorder record.EventRecorder,
 	defaultLocalBackupLocation string,
-	defaultS3 *v1.S3ObjectStore) {
+	defaultS3 *v1.S3ObjectStore,
+	orphanedBackupCleanupInterval time.Duration) {
 
 	controller := &handler{
 		ctx:                     ctx,
+		recorder:                recorder,
 		backups:                 backups,
+		backupRuns:              backupRuns,
 		resourceSets:            resourceSets,
 		secrets:                 secrets,
 		namespaces:              namespaces,
 		discoveryClient:         clientSet.Discovery(),
 		dynamicClient:           dynamicInterface,
+		storageDynamicClient:    dynamicInterface,
+		restConfig:              restConfig,
 		defaultBackupMountPath:  defaultLocalBackupLocation,
 		defaultS3BackupLocation: defaultS3,
 	}
@@ -83,19 +118,68 @@ func Register(
 	controller.kubeSystemNS = string(kubeSystemNS.UID)
 	// Register handlers
 	backups.OnChange(ctx, "backups", controller.OnBackupChange)
+	backups.OnChange(ctx, "backups-scrub", controller.OnBackupScrub)
+
+	if orphanedBackupCleanupInterval > 0 {
+		logrus.Infof("Deleting backup files of this cluster without a backup CR from the default storage location every %v", orphanedBackupCleanupInterval)
+		go controller.cleanupOrphanedBackupsPeriodically(orphanedBackupCleanupInterval)
+	}
 }
 
-func (h *handler) OnBackupChange(_ string, backup *v1.Backup) (*v1.Backup, error) {
-	if backup == nil || backup.DeletionTimestamp != nil {
+func (h *handler) OnBackupChange(key string, backup *v1.Backup) (*v1.Backup, error) {
+	if backup == nil || backup.DeletionTimestamp != nil || backup.Spec.ServiceAccountName == "" {
+		return h.onBackupChange(key, backup)
+	}
+	impersonating, err := h.impersonating(backup)
+	if err != nil {
+		return h.setReconcilingCondition(backup, err)
+	}
+	return impersonating.onBackupChange(key, backup)
+}
+
+func (h *handler) onBackupChange(key string, backup *v1.Backup) (*v1.Backup, error) {
+	if backup == nil {
+		metrics.BackupRemoved(key)
 		return backup, nil
 	}
+	if backup.DeletionTimestamp != nil {
+		return h.onBackupRemove(backup)
+	}
 	logrus.Infof("Processing backup %v", backup.Name)
 
+	if len(backup.Status.QuiescedControllers) > 0 {
+		// controllers are still scaled down from a backup that was interrupted, scale them back up before anything else
+		logrus.Infof("Resuming controllers quiesced by an interrupted backup for backup CR %v", backup.Name)
+		var err error
+		if backup, err = h.resumeControllers(backup); err != nil {
+			return h.setReconcilingCondition(backup, err)
+		}
+	}
+
 	if err := h.validateBackupSpec(backup); err != nil {
 		return h.setReconcilingCondition(backup, err)
 	}
 
-	if backup.Status.LastSnapshotTS != "" {
+	updatedBackup, err := h.syncBackupFilesFinalizer(backup)
+	if err != nil {
+		return h.setReconcilingCondition(backup, err)
+	}
+	backup = updatedBackup
+
+	trigger := pendingTrigger(backup)
+	if trigger != "" {
+		logrus.Infof("Processing backup CR %v triggered by annotation %v=%v", backup.Name, TriggerAnnotation, trigger)
+	} else if backup.Spec.Suspend {
+		return h.suspendedBackup(backup)
+	}
+
+	if trigger == "" && backup.Spec.Schedule == "" && isStalled(backup) {
+		logrus.Infof("Backup CR %v has stalled, update it or set the %v annotation to take the backup again", backup.Name, TriggerAnnotation)
+		return backup, nil
+	}
+
+	// a recurring backup that stalled before its first snapshot waits for its next scheduled time too
+	if trigger == "" && (backup.Status.LastSnapshotTS != "" || isStalled(backup)) {
 		if backup.Spec.Schedule == "" {
 			// Backup CR was meant for one-time backup, and the backup has been completed. Probably here from UpdateStatus call
 			logrus.Infof("Backup CR %v has been processed for one-time backup, returning", backup.Name)
@@ -110,6 +194,18 @@ func (h *handler) OnBackupChange(_ string, backup *v1.Backup) (*v1.Backup, error
 				backup.Status.BackupType = "One-time"
 				updBackupStatus = true
 			}
+			// retention only applies to one-time backups with an explicit retention policy, never the retentionCount default
+			if backup.Spec.RetentionPolicy != nil {
+				deleted, err := h.deleteBackupsFollowingRetentionPolicy(backup)
+				if err != nil {
+					return h.setReconcilingCondition(backup, err)
+				}
+				if backup.Spec.RetentionPolicy.DryRun && !reflect.DeepEqual(deleted, backup.Status.RetentionDryRun) {
+					backup.Status.RetentionDryRun = deleted
+					updBackupStatus = true
+				}
+				h.backups.EnqueueAfter(backup.Name, oneTimeRetentionCheckInterval)
+			}
 			if updBackupStatus {
 				return h.backups.UpdateStatus(backup)
 			}
@@ -127,18 +223,31 @@ func (h *handler) OnBackupChange(_ string, backup *v1.Backup) (*v1.Backup, error
 				after := nextSnapshotTime.Sub(time.Now())
 				h.backups.EnqueueAfter(backup.Name, after)
 				if backup.Generation != backup.Status.ObservedGeneration {
+					// the schedule, time zone or jitter might have changed, so the next snapshot is rescheduled
+					rescheduled, err := h.nextSnapshotTime(backup, time.Now())
+					if err != nil {
+						return h.setReconcilingCondition(backup, err)
+					}
+					backup.Status.NextSnapshotAt = rescheduled.Format(time.RFC3339)
+					h.setScheduleStatus(backup)
+					h.backups.EnqueueAfter(backup.Name, rescheduled.Sub(time.Now()))
 					backup.Status.ObservedGeneration = backup.Generation
 					return h.backups.UpdateStatus(backup)
 				}
 				return backup, nil
 			}
+			if missedSnapshot(backup, nextSnapshotTime, time.Now()) {
+				return h.skipMissedSnapshot(backup, nextSnapshotTime)
+			}
 
 			// proceed with backup only if current time is same as or after nextSnapshotTime
 			logrus.Infof("Processing recurring backup CR %v ", backup.Name)
 		}
 	}
 
-	backupFileName, err := h.generateBackupFilename(backup)
+	run := newBackupRun()
+	run.key = runKey(backup, trigger)
+	backupFileName, err := h.generateBackupFilename(backup, run.startTime)
 	if err != nil {
 		return h.setReconcilingCondition(backup, err)
 	}
@@ -146,30 +255,84 @@ func (h *handler) OnBackupChange(_ string, backup *v1.Backup) (*v1.Backup, error
 
 	// create a temp dir to write all backup files to, delete this before returning.
 	// empty dir param in ioutil.TempDir defaults to os.TempDir
-	tmpBackupPath, err := ioutil.TempDir("", backupFileName)
+	tmpBackupPath, err := ioutil.TempDir("", path.Base(backupFileName))
 	if err != nil {
 		return h.setReconcilingCondition(backup, fmt.Errorf("error creating temp dir: %v", err))
 	}
 	logrus.Infof("Temporary backup path for storing all contents for backup CR %v is %v", backup.Name, tmpBackupPath)
 
-	if err := h.performBackup(backup, tmpBackupPath, backupFileName); err != nil {
+	if err := h.createBackupRun(backup, run, trigger); err != nil {
+		return h.setReconcilingCondition(backup, removeTempUploadDir(tmpBackupPath, err))
+	}
+	metrics.BackupStarted(backup.Name)
+	h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonStarted, "Started backup %v", backupFileName)
+	if backup.Spec.Quiesce {
+		logrus.Infof("Quiescing controllers for backup CR %v", backup.Name)
+		if backup, err = h.quiesceControllers(backup); err != nil {
+			// scale back up whatever was scaled down before the failure
+			if _, resumeErr := h.resumeControllers(backup); resumeErr != nil {
+				err = errors.New(err.Error() + resumeErr.Error())
+			}
+			h.completeBackupRun(backup, run, v1.BackupRunPhaseFailed, err)
+			return h.setReconcilingCondition(backup, removeTempUploadDir(tmpBackupPath, err))
+		}
+	}
+
+	backupCtx, cancel := h.backupContext(backup)
+	err = h.performBackup(backupCtx, backup, tmpBackupPath, backupFileName, run)
+	cancel()
+	if len(backup.Status.QuiescedControllers) > 0 {
+		// always scale the controllers back up, even if the backup failed
+		logrus.Infof("Resuming quiesced controllers for backup CR %v", backup.Name)
+		storageLocationType := backup.Status.StorageLocation
+		updBackup, resumeErr := h.resumeControllers(backup)
+		if resumeErr != nil {
+			if err == nil {
+				err = resumeErr
+			} else {
+				err = errors.New(err.Error() + resumeErr.Error())
+			}
+		} else {
+			backup = updBackup
+			backup.Status.StorageLocation = storageLocationType
+		}
+	}
+	if err != nil {
+		metrics.BackupFailed(backup.Name, time.Since(run.startTime))
+		if recordErr := h.recordFailedBackup(backup, run, err); recordErr != nil {
+			logrus.Errorf("Error recording failed run in history of backup CR %v: %v", backup.Name, recordErr)
+		}
 		removeDirErr := os.RemoveAll(tmpBackupPath)
 		if removeDirErr != nil {
 			return h.setReconcilingCondition(backup, errors.New(err.Error()+removeDirErr.Error()))
 		}
+		if reason, message := h.stallReason(backupCtx, backup); reason != "" {
+			run.logf("%v", message)
+			h.completeBackupRun(backup, run, v1.BackupRunPhaseStalled, err)
+			// retrying right away would most likely time out again, or take the cancelled backup
+			return h.setStalledCondition(backup, trigger, reason, message)
+		}
+		h.completeBackupRun(backup, run, v1.BackupRunPhaseFailed, err)
 		return h.setReconcilingCondition(backup, err)
 	}
+	// the backup file is stored, so the run succeeded even if cleaning up or retention fails below
+	h.completeBackupRun(backup, run, v1.BackupRunPhaseSucceeded, nil)
 
 	if err := os.RemoveAll(tmpBackupPath); err != nil {
 		return h.setReconcilingCondition(backup, err)
 	}
 	// check for retention
-	var cronSchedule cron.Schedule
+	var nextBackupAt time.Time
+	var retentionDryRun []string
 	if backup.Spec.Schedule != "" {
-		if err := h.deleteBackupsFollowingRetentionPolicy(backup); err != nil {
+		deleted, err := h.deleteBackupsFollowingRetentionPolicy(backup)
+		if err != nil {
 			return h.setReconcilingCondition(backup, err)
 		}
-		cronSchedule, err = cron.ParseStandard(backup.Spec.Schedule)
+		if retentionPolicyForBackup(backup).DryRun {
+			retentionDryRun = deleted
+		}
+		nextBackupAt, err = h.nextSnapshotTime(backup, time.Now())
 		if err != nil {
 			return h.setReconcilingCondition(backup, err)
 		}
@@ -181,45 +344,73 @@ func (h *handler) OnBackupChange(_ string, backup *v1.Backup) (*v1.Backup, error
 		if err != nil {
 			return err
 		}
-		// reset conditions to remove the reconciling condition, because as per kstatus lib its presence is considered an error
-		backup.Status.Conditions = []genericcondition.GenericCondition{}
+		// reset conditions to remove the reconciling condition, because as per kstatus lib its presence is considered an error,
+		// but keep the result of the latest scrub unless this backup was verified
+		conditions := []genericcondition.GenericCondition{}
+		if backup.Spec.ScrubSchedule != "" {
+			for _, cond := range backup.Status.Conditions {
+				if cond.Type == v1.BackupConditionVerified {
+					conditions = append(conditions, cond)
+				}
+			}
+		}
+		backup.Status.Conditions = conditions
 
 		condition.Cond(v1.BackupConditionReady).SetStatusBool(backup, true)
 		condition.Cond(v1.BackupConditionReady).Message(backup, "Completed")
 		condition.Cond(v1.BackupConditionUploaded).SetStatusBool(backup, true)
 
 		backup.Status.LastSnapshotTS = time.Now().Format(time.RFC3339)
-		if cronSchedule != nil {
-			nextBackupAt := cronSchedule.Next(time.Now())
-			backup.Status.NextSnapshotAt = nextBackupAt.Format(time.RFC3339)
-			after := nextBackupAt.Sub(time.Now())
-			h.backups.EnqueueAfter(backup.Name, after)
+		if backup.Spec.Schedule != "" {
+			// a triggered backup doesn't move the next scheduled one
+			if trigger == "" || backup.Status.NextSnapshotAt == "" {
+				backup.Status.NextSnapshotAt = nextBackupAt.Format(time.RFC3339)
+				after := nextBackupAt.Sub(time.Now())
+				h.backups.EnqueueAfter(backup.Name, after)
+			}
+			h.setScheduleStatus(backup)
 			backup.Status.BackupType = "Recurring"
 		} else {
 			backup.Status.BackupType = "One-time"
 		}
+		if trigger != "" {
+			backup.Status.LastTrigger = trigger
+		}
 		backup.Status.ObservedGeneration = backup.Generation
 		backup.Status.StorageLocation = storageLocationType
-		backup.Status.Filename = backupFileName + ".tar.gz"
-		if backup.Spec.EncryptionConfigSecretName != "" {
-			backup.Status.Filename += ".enc"
+		backup.Status.Filename = run.filename
+		if run.name != "" {
+			backup.Status.LastSuccessfulRun = run.name
 		}
+		backup.Status.RetentionDryRun = retentionDryRun
+		if run.verification != nil {
+			backup.Status.LastVerification = run.verification
+			message := fmt.Sprintf("Verified %v after upload", run.verification.Filename)
+			if run.verification.Result != v1.BackupResultSucceeded {
+				message = fmt.Sprintf("Verifying %v after upload failed: %v", run.verification.Filename, run.verification.Error)
+			}
+			setVerifiedCondition(backup, run.verification.Result == v1.BackupResultSucceeded, message)
+		}
+		addToHistory(&backup.Status, run.historyEntry(storageLocationType, nil))
 		_, err = h.backups.UpdateStatus(backup)
 		return err
 	})
 	if updateErr != nil {
 		return h.setReconcilingCondition(backup, updateErr)
 	}
+	metrics.BackupSucceeded(backup.Name, time.Since(run.startTime), run.size, run.objectCounts)
+	h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonCompleted, "Completed backup %v in %v", run.filename,
+		time.Since(run.startTime).Round(time.Millisecond))
 	logrus.Infof("Done with backup")
 	return backup, err
 }
 
-func (h *handler) performBackup(backup *v1.Backup, tmpBackupPath, backupFileName string) error {
+func (h *handler) performBackup(ctx context.Context, backup *v1.Backup, tmpBackupPath, backupFileName string, run *backupRun) error {
 	var err error
 	transformerMap := make(map[schema.GroupResource]value.Transformer)
 	if backup.Spec.EncryptionConfigSecretName != "" {
 		logrus.Infof("Processing encryption config %v for backup CR %v", backup.Spec.EncryptionConfigSecretName, backup.Name)
-		transformerMap, err = util.GetEncryptionTransformers(backup.Spec.EncryptionConfigSecretName, h.secrets)
+		transformerMap, err = util.GetEncryptionTransformers(backup.Spec.EncryptionConfigSecretName, backup.Spec.RestrictToNamespace, h.secrets)
 		if err != nil {
 			return err
 		}
@@ -236,11 +427,18 @@ func (h *handler) performBackup(backup *v1.Backup, tmpBackupPath, backupFileName
 		DiscoveryClient: h.discoveryClient,
 		DynamicClient:   h.dynamicClient,
 		TransformerMap:  transformerMap,
+		Namespace:       backup.Spec.RestrictToNamespace,
 	}
-	resourcesWithStatusSubresource, err := rh.GatherResources(h.ctx, resourceSetTemplate.ResourceSelectors)
+	resourcesWithStatusSubresource, err := rh.GatherResources(ctx, resourceSetTemplate.ResourceSelectors)
 	if err != nil {
 		return err
 	}
+	setQuiescedReplicasInBackup(&rh, backup.Status.QuiescedControllers)
+	run.countObjects(&rh)
+	h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonGathered, "Gathered %v objects of %v resource types",
+		run.totalObjects(), len(run.objectCounts))
+	run.logf("Gathered %v objects of %v resource types using resourceSet %v", run.totalObjects(), len(run.objectCounts),
+		backup.Spec.ResourceSetName)
 
 	logrus.Infof("Finished gathering resources for backup CR %v, writing to temp location", backup.Name)
 	err = rh.WriteBackupObjects(tmpBackupPath)
@@ -276,22 +474,32 @@ func (h *handler) performBackup(backup *v1.Backup, tmpBackupPath, backupFileName
 
 	condition.Cond(v1.BackupConditionReady).SetStatusBool(backup, true)
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	gzipFile := backupFileName + ".tar.gz"
 	if backup.Spec.EncryptionConfigSecretName != "" {
 		gzipFile += ".enc"
 	}
+	run.filename = gzipFile
+	metadata := h.backupMetadata(backup, gzipFile, run.startTime)
 	storageLocation := backup.Spec.StorageLocation
 	if storageLocation == nil {
 		logrus.Infof("No storage location specified, checking for default PVC and S3")
 		// use the default location that the controller is configured with
 		if h.defaultBackupMountPath != "" {
 			if err := CreateTarAndGzip(tmpBackupPath, h.defaultBackupMountPath, gzipFile, backup.Name); err != nil {
+				// don't leave a partially written backup file behind
+				os.Remove(filepath.Join(h.defaultBackupMountPath, gzipFile))
 				return err
 			}
 			backup.Status.StorageLocation = util.PVBackup
+			if run.size, err = fileSize(filepath.Join(h.defaultBackupMountPath, gzipFile)); err != nil {
+				return err
+			}
 		} else if h.defaultS3BackupLocation != nil {
 			// not checking for nil, since if this wasn't provided, the default local location would get used
-			if err := h.uploadToS3(backup, h.defaultS3BackupLocation, tmpBackupPath, gzipFile); err != nil {
+			if run.size, err = h.uploadToS3(ctx, backup, h.defaultS3BackupLocation, tmpBackupPath, gzipFile, metadata); err != nil {
 				return err
 			}
 			backup.Status.StorageLocation = util.S3Backup
@@ -300,37 +508,119 @@ func (h *handler) performBackup(backup *v1.Backup, tmpBackupPath, backupFileName
 		}
 	} else if storageLocation.S3 != nil {
 		backup.Status.StorageLocation = util.S3Backup
-		if err := h.uploadToS3(backup, storageLocation.S3, tmpBackupPath, gzipFile); err != nil {
+		if run.size, err = h.uploadToS3(ctx, backup, storageLocation.S3, tmpBackupPath, gzipFile, metadata); err != nil {
 			return err
 		}
 	}
+	h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonUploaded, "Stored %v of size %v in %v storage location",
+		gzipFile, humanReadableSize(run.size), backup.Status.StorageLocation)
+	run.logf("Stored %v of size %v in %v storage location", gzipFile, humanReadableSize(run.size), backup.Status.StorageLocation)
+	if err := h.writeBackupMetadata(backup, metadata); err != nil {
+		return err
+	}
+	if backup.Spec.VerifyAfterUpload {
+		h.verifyAfterUpload(ctx, backup, gzipFile, transformerMap, run)
+	}
+	return nil
+}
+
+// writeBackupMetadata stores the metadata file next to the backup file, which retention and restores use to find the backup files
+// of a Backup CR, whatever its filename template
+func (h *handler) writeBackupMetadata(backup *v1.Backup, metadata *util.BackupMetadata) error {
+	store, err := h.storeForBackup(backup)
+	if err != nil || store == nil {
+		return err
+	}
+	if err := store.writeMetadata(metadata); err != nil {
+		return fmt.Errorf("error writing metadata file for backup file %v: %v", metadata.Filename, err)
+	}
 	return nil
 }
 
+// backupMetadata describes the backup file taken at startTime, it is written to the metadata file and also attached to backup
+// files uploaded to S3 as object tags and user metadata
+func (h *handler) backupMetadata(backup *v1.Backup, gzipFile string, startTime time.Time) *util.BackupMetadata {
+	metadata := &util.BackupMetadata{
+		BackupName:                 backup.Name,
+		ClusterUID:                 h.kubeSystemNS,
+		ClusterName:                util.ClusterName,
+		Filename:                   gzipFile,
+		CreatedAt:                  startTime.Format(time.RFC3339),
+		Encrypted:                  backup.Spec.EncryptionConfigSecretName != "",
+		ResourceSetName:            backup.Spec.ResourceSetName,
+		OperatorVersion:            util.OperatorVersion,
+		EncryptionConfigSecretName: backup.Spec.EncryptionConfigSecretName,
+		DeletionPolicy:             v1.DeletionPolicyRetain,
+	}
+	if backup.Spec.DeletionPolicy != "" {
+		metadata.DeletionPolicy = backup.Spec.DeletionPolicy
+	}
+	for _, key := range backup.Spec.StorageTagLabels {
+		value, ok := backup.Labels[key]
+		if !ok {
+			continue
+		}
+		if metadata.Labels == nil {
+			metadata.Labels = make(map[string]string)
+		}
+		metadata.Labels[key] = value
+	}
+	return metadata
+}
+
 func (h *handler) validateBackupSpec(backup *v1.Backup) error {
 	if backup.Spec.Schedule != "" {
 		_, err := cron.ParseStandard(backup.Spec.Schedule)
 		if err != nil {
 			return fmt.Errorf("error parsing invalid cron string for schedule: %v", err)
 		}
-		if backup.Spec.RetentionCount == 0 {
+		if backup.Spec.RetentionCount == 0 && backup.Spec.RetentionPolicy == nil {
 			backup.Spec.RetentionCount = DefaultRetentionCount
 		}
 	}
+	if err := validateRetentionPolicy(backup.Spec.RetentionPolicy); err != nil {
+		return fmt.Errorf("invalid retention policy: %v", err)
+	}
+	if _, err := scheduleLocation(backup); err != nil {
+		return fmt.Errorf("invalid timeZone: %v", err)
+	}
+	if backup.Spec.JitterWindowSeconds < 0 {
+		return fmt.Errorf("jitterWindowSeconds cannot be negative")
+	}
+	if err := h.validateFilenameTemplate(backup); err != nil {
+		return fmt.Errorf("invalid filenameTemplate: %v", err)
+	}
+	if len(backup.Spec.StorageTagLabels) > util.MaxObjectTagLabels {
+		return fmt.Errorf("storageTagLabels can copy at most %v labels, S3 allows %v tags per object", util.MaxObjectTagLabels, util.MaxObjectTags)
+	}
+	for _, key := range backup.Spec.StorageTagLabels {
+		if strings.HasPrefix(key, util.ObjectTagPrefix) {
+			return fmt.Errorf("storageTagLabels cannot copy label %v, tags starting with %v are set by the operator", key, util.ObjectTagPrefix)
+		}
+	}
+	if backup.Spec.ScrubSchedule != "" {
+		if _, err := cron.ParseStandard(backup.Spec.ScrubSchedule); err != nil {
+			return fmt.Errorf("error parsing invalid cron string for scrubSchedule: %v", err)
+		}
+	}
+	if backup.Spec.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeoutSeconds cannot be negative")
+	}
+	if backup.Spec.StartingDeadlineSeconds != nil && *backup.Spec.StartingDeadlineSeconds < 0 {
+		return fmt.Errorf("startingDeadlineSeconds cannot be negative")
+	}
+	switch backup.Spec.DeletionPolicy {
+	case "", v1.DeletionPolicyRetain, v1.DeletionPolicyDelete:
+	default:
+		return fmt.Errorf("invalid deletionPolicy %v, must be %v or %v", backup.Spec.DeletionPolicy, v1.DeletionPolicyRetain, v1.DeletionPolicyDelete)
+	}
 	return nil
 }
 
-func (h *handler) generateBackupFilename(backup *v1.Backup) (string, error) {
-	currSnapshotTS := time.Now().Format(time.RFC3339)
-	// on OS X writing file with `:` converts colon to forward slash
-	currTSForFilename := strings.Replace(currSnapshotTS, ":", "-", -1)
-	backupFileName := fmt.Sprintf("%s-%s-%s", backup.Name, h.kubeSystemNS, currTSForFilename)
-	return backupFileName, nil
-}
-
 // https://github.com/kubernetes-sigs/cli-utils/tree/master/pkg/kstatus
 // Reconciling and Stalled conditions are present and with a value of true whenever something unusual happens.
 func (h *handler) setReconcilingCondition(backup *v1.Backup, originalErr error) (*v1.Backup, error) {
+	h.recorder.Event(backup, corev1.EventTypeWarning, eventReasonFailed, originalErr.Error())
 	if !condition.Cond(v1.BackupConditionReconciling).IsUnknown(backup) && condition.Cond(v1.BackupConditionReconciling).GetReason(backup) == "Error" {
 		reconcileMsg := condition.Cond(v1.BackupConditionReconciling).GetMessage(backup)
 		if strings.Contains(reconcileMsg, originalErr.Error()) {
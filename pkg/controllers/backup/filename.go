@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+)
+
+// defaultFilenameTemplate produces the filenames used before filename templates were supported,
+// for example backupName-kubeSystemUID-2020-09-26T12-49-34-07-00
+const defaultFilenameTemplate = "{{.BackupName}}-{{.ClusterUID}}-{{.Timestamp}}"
+
+// filenameTemplateData holds the placeholders available in a Backup's filenameTemplate
+type filenameTemplateData struct {
+	ClusterName string
+	ClusterUID  string
+	BackupName  string
+	Year        string
+	Month       string
+	Day         string
+	Hour        string
+	Minute      string
+	Second      string
+	// Timestamp is the RFC3339 timestamp with ":" replaced by "-"
+	Timestamp string
+	Labels    map[string]string
+}
+
+// generateBackupFilename returns the path of the backup file relative to the storage location, without the .tar.gz extension.
+// Date parts are in the Backup's time zone
+func (h *handler) generateBackupFilename(backup *v1.Backup, snapshotTime time.Time) (string, error) {
+	filenameTemplate := backup.Spec.FilenameTemplate
+	if filenameTemplate == "" {
+		filenameTemplate = defaultFilenameTemplate
+	}
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(filenameTemplate)
+	if err != nil {
+		return "", err
+	}
+	location, err := scheduleLocation(backup)
+	if err != nil {
+		return "", err
+	}
+	t := snapshotTime.In(location)
+	data := filenameTemplateData{
+		ClusterName: util.ClusterName,
+		ClusterUID:  h.kubeSystemNS,
+		BackupName:  backup.Name,
+		Year:        t.Format("2006"),
+		Month:       t.Format("01"),
+		Day:         t.Format("02"),
+		Hour:        t.Format("15"),
+		Minute:      t.Format("04"),
+		Second:      t.Format("05"),
+		// on OS X writing file with `:` converts colon to forward slash
+		Timestamp: strings.Replace(t.Format(time.RFC3339), ":", "-", -1),
+		Labels:    backup.Labels,
+	}
+	var filename bytes.Buffer
+	if err := tmpl.Execute(&filename, data); err != nil {
+		return "", err
+	}
+	cleaned := path.Clean(filename.String())
+	if cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.HasSuffix(filename.String(), "/") {
+		return "", fmt.Errorf("filename %q must be a relative path to a file within the storage location", filename.String())
+	}
+	return cleaned, nil
+}
+
+// validateFilenameTemplate makes sure the Backup's filenameTemplate renders and gives each backup a file of its own. A template
+// rendering the same filename for backups taken a second apart would overwrite the previous backup file on every run
+func (h *handler) validateFilenameTemplate(backup *v1.Backup) error {
+	snapshotTime := time.Now()
+	filename, err := h.generateBackupFilename(backup, snapshotTime)
+	if err != nil {
+		return err
+	}
+	next, err := h.generateBackupFilename(backup, snapshotTime.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	if next == filename {
+		return fmt.Errorf("backups taken a second apart would get the same filename %v, include {{.Timestamp}} or {{.Second}}", filename)
+	}
+	return nil
+}
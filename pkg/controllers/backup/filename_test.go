@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newFilenameBackup(filenameTemplate, timeZone string) *v1.Backup {
+	return &v1.Backup{
+		ObjectMeta: k8sv1.ObjectMeta{Name: "daily", Labels: map[string]string{"team": "a"}},
+		Spec:       v1.BackupSpec{FilenameTemplate: filenameTemplate, TimeZone: timeZone},
+	}
+}
+
+func TestGenerateBackupFilename(t *testing.T) {
+	snapshotTime := time.Date(2026, time.March, 15, 23, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name             string
+		filenameTemplate string
+		timeZone         string
+		want             string
+		wantErr          bool
+	}{
+		{
+			name:     "default template",
+			timeZone: "UTC",
+			want:     "daily-uid-2026-03-15T23-04-05Z",
+		},
+		{
+			name:             "date parts are in the time zone of the Backup",
+			filenameTemplate: "{{.Year}}/{{.Month}}/{{.Day}}/{{.Hour}}{{.Minute}}{{.Second}}-{{.Timestamp}}",
+			timeZone:         "Europe/Berlin",
+			want:             "2026/03/16/000405-2026-03-16T00-04-05+01-00",
+		},
+		{
+			name:             "labels",
+			filenameTemplate: "{{.Labels.team}}/{{.BackupName}}-{{.Timestamp}}",
+			timeZone:         "UTC",
+			want:             "a/daily-2026-03-15T23-04-05Z",
+		},
+		{
+			name:             "paths are cleaned",
+			filenameTemplate: "a//b/./{{.BackupName}}",
+			timeZone:         "UTC",
+			want:             "a/b/daily",
+		},
+		{
+			name:             "unknown placeholder",
+			filenameTemplate: "{{.Unknown}}",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+		{
+			name:             "invalid template",
+			filenameTemplate: "{{.BackupName",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+		{
+			name:             "absolute path",
+			filenameTemplate: "/{{.BackupName}}",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+		{
+			name:             "path out of the storage location",
+			filenameTemplate: "../{{.BackupName}}",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+		{
+			name:             "path cleaned to out of the storage location",
+			filenameTemplate: "a/../../{{.BackupName}}",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+		{
+			name:             "directory",
+			filenameTemplate: "{{.BackupName}}/",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+		{
+			name:             "missing label",
+			filenameTemplate: "{{.Labels.missing}}",
+			timeZone:         "UTC",
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handler{kubeSystemNS: "uid"}
+			got, err := h.generateBackupFilename(newFilenameBackup(tt.filenameTemplate, tt.timeZone), snapshotTime)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("generateBackupFilename() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("generateBackupFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilenameTemplate(t *testing.T) {
+	tests := []struct {
+		name             string
+		filenameTemplate string
+		wantErr          bool
+	}{
+		{
+			name: "default template",
+		},
+		{
+			name:             "timestamp",
+			filenameTemplate: "{{.BackupName}}-{{.Timestamp}}",
+		},
+		{
+			name:             "seconds",
+			filenameTemplate: "{{.Year}}{{.Month}}{{.Day}}{{.Hour}}{{.Minute}}{{.Second}}",
+		},
+		{
+			name:             "static template",
+			filenameTemplate: "{{.BackupName}}",
+			wantErr:          true,
+		},
+		{
+			name:             "only minutes",
+			filenameTemplate: "{{.BackupName}}-{{.Hour}}{{.Minute}}",
+			wantErr:          true,
+		},
+		{
+			name:             "invalid template",
+			filenameTemplate: "{{.Unknown}}",
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handler{kubeSystemNS: "uid"}
+			if err := h.validateFilenameTemplate(newFilenameBackup(tt.filenameTemplate, "UTC")); (err != nil) != tt.wantErr {
+				t.Errorf("validateFilenameTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
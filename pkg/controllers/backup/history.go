@@ -0,0 +1,167 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
+	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const MaxBackupHistory = 10
+
+// backupRun collects information about a single execution of a Backup CR
+type backupRun struct {
+	// name of the BackupRun CR recording this execution
+	name string
+	// key identifies the execution across the retries of a failed attempt
+	key          string
+	startTime    time.Time
+	filename     string
+	size         int64
+	objectCounts map[string]int
+	logs         []string
+	verification *v1.BackupVerification
+}
+
+func newBackupRun() *backupRun {
+	return &backupRun{
+		startTime:    time.Now(),
+		objectCounts: make(map[string]int),
+	}
+}
+
+// countObjects records the number of gathered objects per GVR, keyed as group/version/resource, for example apps/v1/deployments
+func (r *backupRun) countObjects(rh *resourcesets.ResourceHandler) {
+	for gvResource, resObjects := range rh.GVResourceToObjects {
+		r.objectCounts[metrics.ResourceLabel(gvResource.GroupVersion, gvResource.Name)] += len(resObjects)
+	}
+}
+
+// logf adds a line to the summary of the execution shown in its BackupRun
+func (r *backupRun) logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *backupRun) totalObjects() int {
+	total := 0
+	for _, count := range r.objectCounts {
+		total += count
+	}
+	return total
+}
+
+func (r *backupRun) historyEntry(storageLocation string, runErr error) v1.BackupHistoryEntry {
+	entry := v1.BackupHistoryEntry{
+		Filename:        r.filename,
+		StorageLocation: storageLocation,
+		SizeBytes:       r.size,
+		ObjectCounts:    r.objectCounts,
+		StartTime:       r.startTime.Format(time.RFC3339),
+		Duration:        time.Since(r.startTime).Round(time.Millisecond).String(),
+		Result:          v1.BackupResultSucceeded,
+		Run:             r.key,
+		Attempts:        1,
+	}
+	if runErr != nil {
+		entry.Result = v1.BackupResultFailed
+		entry.Error = runErr.Error()
+	}
+	return entry
+}
+
+// runKey identifies the execution of the Backup about to start, which stays the same while a failed attempt is retried: the trigger
+// of a triggered backup, the scheduled time of a recurring backup, or else the generation of the Backup
+func runKey(backup *v1.Backup, trigger string) string {
+	if trigger != "" {
+		return "trigger " + trigger
+	}
+	if backup.Spec.Schedule != "" && backup.Status.NextSnapshotAt != "" {
+		return "scheduled " + backup.Status.NextSnapshotAt
+	}
+	return fmt.Sprintf("generation %v", backup.Generation)
+}
+
+// addToHistory adds the entry to the Backup's history and updates the summary. An attempt of the run whose failure is the latest
+// entry replaces it, otherwise the entry is prepended, keeping at most MaxBackupHistory entries
+func addToHistory(status *v1.BackupStatus, entry v1.BackupHistoryEntry) {
+	if len(status.History) > 0 && status.History[0].Result == v1.BackupResultFailed && entry.Run != "" && status.History[0].Run == entry.Run {
+		entry.Attempts = status.History[0].Attempts + 1
+		status.History[0] = entry
+	} else {
+		status.History = append([]v1.BackupHistoryEntry{entry}, status.History...)
+	}
+	if len(status.History) > MaxBackupHistory {
+		status.History = status.History[:MaxBackupHistory]
+	}
+	if entry.Result == v1.BackupResultSucceeded {
+		status.LastSuccess = &entry
+	}
+	status.Summary = historySummary(status.History)
+}
+
+func historySummary(history []v1.BackupHistoryEntry) string {
+	if len(history) == 0 {
+		return ""
+	}
+	latest := history[0]
+	succeeded := 0
+	for _, entry := range history {
+		if entry.Result == v1.BackupResultSucceeded {
+			succeeded++
+		}
+	}
+	total := 0
+	for _, count := range latest.ObjectCounts {
+		total += count
+	}
+	var summary string
+	if latest.Result == v1.BackupResultSucceeded {
+		summary = fmt.Sprintf("Last backup succeeded at %v: %v objects of %v resource types, %v in %v", latest.StartTime, total,
+			len(latest.ObjectCounts), humanReadableSize(latest.SizeBytes), latest.Duration)
+	} else {
+		summary = fmt.Sprintf("Last backup failed at %v after %v: %v", latest.StartTime, latest.Duration, latest.Error)
+		if latest.Attempts > 1 {
+			summary = fmt.Sprintf("%s, after %v attempts", summary, latest.Attempts)
+		}
+	}
+	return fmt.Sprintf("%s. %v of the last %v backups succeeded", summary, succeeded, len(history))
+}
+
+// recordFailedBackup adds a failed run to the Backup's history, the error itself is set on the conditions by setReconcilingCondition
+func (h *handler) recordFailedBackup(backup *v1.Backup, run *backupRun, runErr error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updBackup, err := h.backups.Get(backup.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		addToHistory(&updBackup.Status, run.historyEntry(backup.Status.StorageLocation, runErr))
+		_, err = h.backups.UpdateStatus(updBackup)
+		return err
+	})
+}
+
+func humanReadableSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func fileSize(path string) (int64, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("error getting size of backup file %v: %v", path, err)
+	}
+	return fileInfo.Size(), nil
+}
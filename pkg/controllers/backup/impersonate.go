@@ -0,0 +1,28 @@
+package backup
+
+import (
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// impersonating returns a copy of the handler whose clients for gathering resources and quiescing controllers act as the Backup's
+// serviceAccountName. Credentials of storage locations are still read as the operator
+func (h *handler) impersonating(backup *v1.Backup) (*handler, error) {
+	config := util.ServiceAccountConfig(h.restConfig, backup.Spec.RestrictToNamespace, backup.Spec.ServiceAccountName)
+	logrus.Debugf("Processing backup CR %v as %v", backup.Name, config.Impersonate.UserName)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	impersonating := *h
+	impersonating.discoveryClient = discoveryClient
+	impersonating.dynamicClient = dynamicClient
+	return &impersonating, nil
+}
@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/slice"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	backupFilesFinalizer = "resources.cattle.io/backup-files"
+	// one-time backups with a retention policy are checked periodically, since no new backup triggers the check for them
+	oneTimeRetentionCheckInterval = time.Hour
+)
+
+// syncBackupFilesFinalizer adds the finalizer that deletes the backup files along with the Backup CR if its deletionPolicy is Delete,
+// and removes it otherwise
+func (h *handler) syncBackupFilesFinalizer(backup *v1.Backup) (*v1.Backup, error) {
+	wantFinalizer := backup.Spec.DeletionPolicy == v1.DeletionPolicyDelete
+	if wantFinalizer == slice.ContainsString(backup.Finalizers, backupFilesFinalizer) {
+		return backup, nil
+	}
+	backup = backup.DeepCopy()
+	if wantFinalizer {
+		logrus.Infof("Adding finalizer to delete backup files along with backup CR %v", backup.Name)
+		backup.Finalizers = append(backup.Finalizers, backupFilesFinalizer)
+	} else {
+		logrus.Infof("Removing finalizer for deleting backup files from backup CR %v", backup.Name)
+		backup.Finalizers = removeFinalizer(backup.Finalizers, backupFilesFinalizer)
+	}
+	return h.backups.Update(backup)
+}
+
+// onBackupRemove deletes all backup files of a Backup CR with deletionPolicy Delete, before removing the finalizer
+func (h *handler) onBackupRemove(backup *v1.Backup) (*v1.Backup, error) {
+	if !slice.ContainsString(backup.Finalizers, backupFilesFinalizer) {
+		return backup, nil
+	}
+	store, err := h.storeForBackup(backup)
+	if err != nil {
+		return h.setReconcilingCondition(backup, fmt.Errorf("error deleting backup files: %v", err))
+	}
+	if store != nil {
+		backupFiles, err := h.backupFiles(store, backup, ".tar.gz", ".tar.gz.enc")
+		if err != nil {
+			return h.setReconcilingCondition(backup, fmt.Errorf("error listing backup files to delete: %v", err))
+		}
+		for _, file := range backupFiles {
+			logrus.Infof("Deleting backup file %v from %v since backup CR %v is being deleted", file.filename, store, backup.Name)
+			if err := removeWithMetadata(store, file); err != nil {
+				return h.setReconcilingCondition(backup, fmt.Errorf("error deleting backup file %v: %v", file.filename, err))
+			}
+			h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonFileDeleted, "Deleted %v following deletionPolicy %v", file.filename,
+				v1.DeletionPolicyDelete)
+		}
+	}
+	updatedBackup := backup.DeepCopy()
+	updatedBackup.Finalizers = removeFinalizer(updatedBackup.Finalizers, backupFilesFinalizer)
+	return h.backups.Update(updatedBackup)
+}
+
+// deleteOrphanedBackups deletes files from the operator's default storage location that were created for this cluster by Backup CRs
+// with deletionPolicy Delete that don't exist anymore. The cluster, Backup CR and deletionPolicy are read from the metadata file or
+// object tags, files without them are never deleted, since the deletionPolicy they were taken with is unknown
+func (h *handler) deleteOrphanedBackups() error {
+	store, err := h.defaultStore()
+	if err != nil || store == nil {
+		return err
+	}
+	backupFiles, err := listWithMetadata(store, ".tar.gz", ".tar.gz.enc")
+	if err != nil {
+		return err
+	}
+	existingBackups := make(map[string]bool)
+	for _, file := range backupFiles {
+		if file.metadata == nil || file.metadata.ClusterUID != h.kubeSystemNS || file.metadata.DeletionPolicy != v1.DeletionPolicyDelete {
+			continue
+		}
+		backupName := file.metadata.BackupName
+		exists, checked := existingBackups[backupName]
+		if !checked {
+			_, err := h.backups.Get(backupName, k8sv1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			exists = err == nil
+			existingBackups[backupName] = exists
+		}
+		if exists {
+			continue
+		}
+		logrus.Infof("Deleting orphaned backup file %v from %v, backup CR %v with deletionPolicy %v doesn't exist anymore", file.filename,
+			store, backupName, v1.DeletionPolicyDelete)
+		if err := removeWithMetadata(store, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *handler) cleanupOrphanedBackupsPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+			logrus.Infof("Checking default storage location for orphaned backup files")
+			if err := h.deleteOrphanedBackups(); err != nil {
+				logrus.Errorf("Error deleting orphaned backup files: %v", err)
+			}
+		}
+	}
+}
+
+func removeFinalizer(finalizers []string, finalizer string) []string {
+	var result []string
+	for _, f := range finalizers {
+		if f != finalizer {
+			result = append(result, f)
+		}
+	}
+	return result
+}
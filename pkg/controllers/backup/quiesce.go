@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"fmt"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// quiesceControllers scales down the controllers listed in the ResourceSet's ControllerReferences before gathering resources.
+// The original replicas are saved on the Backup status first, so they can be restored even if the operator crashes mid-backup
+func (h *handler) quiesceControllers(backup *v1.Backup) (*v1.Backup, error) {
+	resourceSetTemplate, err := h.resourceSets.Get(backup.Spec.ResourceSetName, k8sv1.GetOptions{})
+	if err != nil {
+		return backup, err
+	}
+	if len(resourceSetTemplate.ControllerReferences) == 0 {
+		logrus.Infof("ResourceSet %v has no controllerReferences, nothing to quiesce for backup CR %v", resourceSetTemplate.Name, backup.Name)
+		return backup, nil
+	}
+
+	var quiesced []v1.ControllerReference
+	for _, controllerRef := range resourceSetTemplate.ControllerReferences {
+		if backup.Spec.RestrictToNamespace != "" && controllerRef.Namespace != backup.Spec.RestrictToNamespace {
+			logrus.Infof("Not quiescing controllerRef %v outside of namespace %v", controllerRef.Name, backup.Spec.RestrictToNamespace)
+			continue
+		}
+		controllerObj, _ := h.getObjFromControllerRef(controllerRef)
+		if controllerObj == nil {
+			continue
+		}
+		replicas, found := getReplicas(controllerObj)
+		if !found {
+			logrus.Errorf("Invalid controllerRef %v, replicas not found, skipping it", controllerRef.Name)
+			continue
+		}
+		controllerRef.Replicas = replicas
+		quiesced = append(quiesced, controllerRef)
+	}
+
+	// save the current replicas before scaling anything down
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updBackup, err := h.backups.Get(backup.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updBackup.Status.QuiescedControllers = quiesced
+		updBackup, err = h.backups.UpdateStatus(updBackup)
+		if err == nil {
+			backup = updBackup
+		}
+		return err
+	})
+	if err != nil {
+		return backup, fmt.Errorf("error saving replicas of controllers to quiesce: %v", err)
+	}
+
+	for _, controllerRef := range quiesced {
+		if err := h.scaleControllerRef(controllerRef, 0); err != nil {
+			return backup, err
+		}
+		h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonScaledDown, "Scaled down controllerRef %v/%v/%v from %v replicas",
+			controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, controllerRef.Replicas)
+	}
+	return backup, nil
+}
+
+// resumeControllers scales the controllers recorded on the Backup status back to their original replicas and clears the record
+func (h *handler) resumeControllers(backup *v1.Backup) (*v1.Backup, error) {
+	var errList []error
+	for _, controllerRef := range backup.Status.QuiescedControllers {
+		if err := h.scaleControllerRef(controllerRef, controllerRef.Replicas); err != nil {
+			errList = append(errList, err)
+			continue
+		}
+		h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonScaledUp, "Scaled up controllerRef %v/%v/%v to %v replicas",
+			controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, controllerRef.Replicas)
+	}
+	if len(errList) > 0 {
+		// keep the record on the status so that the next reconcile retries scaling up
+		return backup, fmt.Errorf("error resuming quiesced controllers: %v", errList)
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updBackup, err := h.backups.Get(backup.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updBackup.Status.QuiescedControllers = nil
+		updBackup, err = h.backups.UpdateStatus(updBackup)
+		if err == nil {
+			backup = updBackup
+		}
+		return err
+	})
+	return backup, err
+}
+
+func (h *handler) scaleControllerRef(controllerRef v1.ControllerReference, replicas int32) error {
+	controllerObj, dr := h.getObjFromControllerRef(controllerRef)
+	if controllerObj == nil {
+		// controller doesn't exist anymore, nothing to scale
+		return nil
+	}
+	spec, specFound := controllerObj.Object["spec"].(map[string]interface{})
+	if !specFound {
+		return fmt.Errorf("invalid controllerRef %v, spec not found", controllerRef.Name)
+	}
+	spec["replicas"] = replicas
+	logrus.Infof("Scaling controllerRef %v/%v/%v to %v", controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, replicas)
+	if _, err := dr.Update(h.ctx, controllerObj, k8sv1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error scaling %v/%v/%v to %v: %v", controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, replicas, err)
+	}
+	return nil
+}
+
+func (h *handler) getObjFromControllerRef(controllerRef v1.ControllerReference) (*unstructured.Unstructured, dynamic.ResourceInterface) {
+	logrus.Infof("Processing controllerRef %v/%v/%v", controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name)
+	var dr dynamic.ResourceInterface
+	gv, err := schema.ParseGroupVersion(controllerRef.APIVersion)
+	if err != nil {
+		logrus.Errorf("Error parsing apiverion %v for controllerRef %v, skipping it", controllerRef.APIVersion, controllerRef.Name)
+		return nil, dr
+	}
+	gvr := gv.WithResource(controllerRef.Resource)
+	dr = h.dynamicClient.Resource(gvr)
+	if controllerRef.Namespace != "" {
+		dr = h.dynamicClient.Resource(gvr).Namespace(controllerRef.Namespace)
+	}
+	controllerObj, err := dr.Get(h.ctx, controllerRef.Name, k8sv1.GetOptions{})
+	if err != nil {
+		logrus.Warnf("Error getting object for controllerRef %v, skipping it", controllerRef.Name)
+		return nil, dr
+	}
+	return controllerObj, dr
+}
+
+// setQuiescedReplicasInBackup replaces the scaled down replicas of quiesced controllers in the gathered objects with their
+// original values, so that restoring the backup doesn't leave these controllers at 0 replicas
+func setQuiescedReplicasInBackup(rh *resourcesets.ResourceHandler, quiesced []v1.ControllerReference) {
+	for _, controllerRef := range quiesced {
+		for gvResource, resObjects := range rh.GVResourceToObjects {
+			if gvResource.GroupVersion.String() != controllerRef.APIVersion || gvResource.Name != controllerRef.Resource {
+				continue
+			}
+			for _, resObj := range resObjects {
+				if resObj.GetName() != controllerRef.Name || resObj.GetNamespace() != controllerRef.Namespace {
+					continue
+				}
+				if spec, ok := resObj.Object["spec"].(map[string]interface{}); ok {
+					spec["replicas"] = int64(controllerRef.Replicas)
+				}
+			}
+		}
+	}
+}
+
+func getReplicas(controllerObj *unstructured.Unstructured) (int32, bool) {
+	spec, specFound := controllerObj.Object["spec"].(map[string]interface{})
+	if !specFound {
+		return 0, false
+	}
+	replicas, int32replicaFound := spec["replicas"].(int32)
+	if !int32replicaFound {
+		int64replica, int64replicaFound := spec["replicas"].(int64)
+		if !int64replicaFound {
+			return 0, false
+		}
+		replicas = int32(int64replica)
+	}
+	return replicas, true
+}
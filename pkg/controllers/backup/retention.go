@@ -2,148 +2,128 @@ package backup
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
-	"sort"
 	"strings"
 	"time"
 
-	"github.com/minio/minio-go/v6"
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
-	"github.com/rancher/backup-restore-operator/pkg/objectstore"
+	"github.com/rancher/backup-restore-operator/pkg/util"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type backupInfo struct {
 	filename          string
 	creationTimestamp time.Time
+	metadata          *util.BackupMetadata
 }
 
-func (h *handler) deleteBackupsFollowingRetentionPolicy(backup *v1.Backup) error {
-	retentionCount := int(backup.Spec.RetentionCount)
-	if backup.Spec.StorageLocation == nil {
-		if h.defaultBackupMountPath != "" {
-			return h.deleteBackupsFromMountPath(retentionCount, h.defaultBackupMountPath, backup.Name, backup.Spec.EncryptionConfigSecretName != "")
-		} else if h.defaultS3BackupLocation != nil {
-			// not checking for nil, since if this wasn't provided, the default local location would get used
-			s3Client, err := objectstore.GetS3Client(h.ctx, h.defaultS3BackupLocation, h.dynamicClient)
-			if err != nil {
-				return err
-			}
-			return h.deleteS3Backups(backup, h.defaultS3BackupLocation, s3Client, retentionCount, backup.Spec.EncryptionConfigSecretName != "")
-		}
-	} else if backup.Spec.StorageLocation.S3 != nil {
-		s3Client, err := objectstore.GetS3Client(h.ctx, backup.Spec.StorageLocation.S3, h.dynamicClient)
-		if err != nil {
-			return err
-		}
-		return h.deleteS3Backups(backup, backup.Spec.StorageLocation.S3, s3Client, retentionCount, backup.Spec.EncryptionConfigSecretName != "")
+// deleteBackupsFollowingRetentionPolicy applies the Backup's retention policy to the backups in its storage location.
+// In dry-run mode nothing is deleted, and the names of the backups that would have been deleted are returned instead
+func (h *handler) deleteBackupsFollowingRetentionPolicy(backup *v1.Backup) ([]string, error) {
+	store, err := h.storeForBackup(backup)
+	if err != nil || store == nil {
+		return nil, err
 	}
-	return nil
-}
-
-func (h *handler) deleteBackupsFromMountPath(retentionCount int, backupLocation, name string, encrypted bool) error {
-	var fileMatchPattern string
-	if encrypted {
-		fileMatchPattern = filepath.Join(backupLocation, fmt.Sprintf("%s-%s*.tar.gz.enc", name, h.kubeSystemNS))
-	} else {
-		fileMatchPattern = filepath.Join(backupLocation, fmt.Sprintf("%s-%s*.tar.gz", name, h.kubeSystemNS))
+	suffix := ".tar.gz"
+	if backup.Spec.EncryptionConfigSecretName != "" {
+		suffix = ".tar.gz.enc"
 	}
-	logrus.Infof("Finding files starting with %v", fileMatchPattern)
-	fileMatches, err := filepath.Glob(fileMatchPattern)
+	backupFiles, err := h.backupFiles(store, backup, suffix)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if len(fileMatches) <= retentionCount {
-		return nil
+	policy := retentionPolicyForBackup(backup)
+	return h.applyRetentionPolicy(backup, policy, filterByTags(backupFiles, policy.TagSelector), func(file backupInfo) error {
+		return removeWithMetadata(store, file)
+	})
+}
+
+// filterByTags returns the backup files with all the tags of the selector. Files without metadata, which were taken before
+// backup files were tagged, never match a selector
+func filterByTags(backupFiles []backupInfo, selector map[string]string) []backupInfo {
+	if len(selector) == 0 {
+		return backupFiles
+	}
+	var matching []backupInfo
+	for _, file := range backupFiles {
+		if file.metadata != nil && file.metadata.MatchesTags(selector) {
+			matching = append(matching, file)
+		}
 	}
-	var backupFiles []backupInfo
-	for _, file := range fileMatches {
-		fileInfo, err := os.Stat(file)
-		if err != nil {
-			logrus.Errorf("Error getting file information for %v: %v", file, err)
+	return matching
+}
+
+// applyRetentionPolicy is the storage independent part of retention, it deletes the backups not kept by the policy using deleteFunc
+func (h *handler) applyRetentionPolicy(backup *v1.Backup, policy v1.RetentionPolicy, backupFiles []backupInfo,
+	deleteFunc func(backupInfo) error) ([]string, error) {
+	var deleted []string
+	for _, file := range backupsToDelete(backupFiles, policy, time.Now()) {
+		if policy.DryRun {
+			logrus.Infof("Retention dry-run: backup file %v created at %v would be deleted", file.filename, file.creationTimestamp)
+			deleted = append(deleted, file.filename)
 			continue
 		}
-		b := backupInfo{
-			filename:          fileInfo.Name(),
-			creationTimestamp: fileInfo.ModTime(),
+		logrus.Infof("Backup file %v was created at %v, deleting it to follow the backup's retention policy", file.filename, file.creationTimestamp)
+		if err := deleteFunc(file); err != nil {
+			return deleted, err
 		}
-		backupFiles = append(backupFiles, b)
+		h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonRetentionDeleted, "Deleted %v to follow retention policy", file.filename)
+		deleted = append(deleted, file.filename)
 	}
-	sort.Slice(backupFiles, func(i, j int) bool {
-		return !backupFiles[i].creationTimestamp.Before(backupFiles[j].creationTimestamp)
-	})
-	for _, file := range backupFiles[retentionCount:] {
-		logrus.Infof("File %v was created at %v, deleting it to follow backup's policy of retaining %v backups", file.filename, file.creationTimestamp, retentionCount)
-		if err := os.Remove(filepath.Join(backupLocation, file.filename)); err != nil {
-			return err
-		}
+	if policy.DryRun && len(deleted) > 0 {
+		h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonRetentionDryRun, "Retention policy would delete %v backups", len(deleted))
 	}
-	return nil
+	return deleted, nil
 }
 
-func (h *handler) deleteS3Backups(backup *v1.Backup, s3 *v1.S3ObjectStore, svc *minio.Client, retentionCount int, encrypted bool) error {
-	// Create a done channel to control 'ListObjectsV2' go routine.
-	doneCh := make(chan struct{})
-
-	// Indicate to our routine to exit cleanly upon return.
-	defer close(doneCh)
+// backupTimestamp parses the time a backup without metadata file was taken from its filename, for example
+// backupName-kubeSystemUID-2020-09-26T12-49-34-07-00.tar.gz, and falls back to the given time if it cannot be parsed
+func (h *handler) backupTimestamp(backup *v1.Backup, filename string, fallback time.Time) time.Time {
+	prefix := fmt.Sprintf("%s-%s-", backup.Name, h.kubeSystemNS)
+	ts := strings.TrimPrefix(filename, prefix)
+	ts = strings.TrimSuffix(strings.TrimSuffix(ts, ".enc"), ".tar.gz")
+	timestamp, err := parseFilenameTimestamp(ts)
+	if err != nil {
+		logrus.Warnf("Error parsing timestamp from backup filename %v, using its modification time instead: %v", filename, err)
+		return fallback
+	}
+	return timestamp
+}
 
-	isRecursive := false
-	prefix := ""
-	if len(s3.Folder) != 0 {
-		prefix = s3.Folder
-		// Recurse will show us the files in the folder
-		isRecursive = true
+// parseFilenameTimestamp reverses the replacement of ":" by "-" done by generateBackupFilename on a RFC3339 timestamp
+func parseFilenameTimestamp(ts string) (time.Time, error) {
+	// 2020-09-26T12-49-34-07-00 or 2020-09-26T12-49-34Z
+	split := strings.SplitN(ts, "T", 2)
+	if len(split) != 2 || len(split[1]) < len("15-04-05") {
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", ts)
 	}
-	objectCh := svc.ListObjects(s3.BucketName, prefix, isRecursive, doneCh)
-	// default-backup-([a-z0-9-]).*([tar]).gz
-	// default-test-ecm-backup-24e1b8ce-1f00-4bbe-94bb-248ad7606dc8-([0-9-#]).*tar.gz$ OR
-	// default-test-ecm-backup-24e1b8ce-1f00-4bbe-94bb-248ad7606dc8-([0-9-#]).*tar.gz.enc$
-	var re *regexp.Regexp
-	if encrypted {
-		re = regexp.MustCompile(fmt.Sprintf("%s-%s-([0-9-#]).*tar.gz.enc$", backup.Name, h.kubeSystemNS))
-	} else {
-		re = regexp.MustCompile(fmt.Sprintf("%s-%s-([0-9-#]).*tar.gz$", backup.Name, h.kubeSystemNS))
+	clock, zone := split[1][:len("15-04-05")], split[1][len("15-04-05"):]
+	clock = strings.Replace(clock, "-", ":", -1)
+	if zone != "Z" && len(zone) == len("-07-00") {
+		zone = zone[:3] + ":" + zone[4:]
 	}
-	var backupFiles []backupInfo
-	for object := range objectCh {
-		if object.Err != nil {
-			logrus.Error("error to fetch s3 file:", object.Err)
-			return object.Err
-		}
-		// only parse backup file names that matches backup format
-		if re.MatchString(object.Key) {
-			filename := object.Key
+	return time.Parse(time.RFC3339, fmt.Sprintf("%sT%s%s", split[0], clock, zone))
+}
 
-			if len(s3.Folder) != 0 {
-				// example object.Key with folder: folder/timestamp_etcd.zip
-				// folder and separator needs to be stripped so time can be parsed below
-				logrus.Debugf("Stripping [%s] from [%s]", fmt.Sprintf("%s/", prefix), filename)
-				filename = strings.TrimPrefix(filename, fmt.Sprintf("%s/", prefix))
-			}
-			b := backupInfo{
-				filename:          object.Key,
-				creationTimestamp: object.LastModified,
-			}
-			backupFiles = append(backupFiles, b)
-		}
+// retentionPolicyForBackup returns the Backup's retention policy, or one equivalent to its retentionCount
+func retentionPolicyForBackup(backup *v1.Backup) v1.RetentionPolicy {
+	if backup.Spec.RetentionPolicy != nil {
+		return *backup.Spec.RetentionPolicy
 	}
-	if len(backupFiles) <= retentionCount {
+	return v1.RetentionPolicy{KeepLast: int(backup.Spec.RetentionCount)}
+}
+
+func validateRetentionPolicy(policy *v1.RetentionPolicy) error {
+	if policy == nil {
 		return nil
 	}
-	sort.Slice(backupFiles, func(i, j int) bool {
-		return !backupFiles[i].creationTimestamp.Before(backupFiles[j].creationTimestamp)
-	})
-	for _, backupFile := range backupFiles[retentionCount:] {
-		logrus.Infof("Deleting s3 backup file [%s] to follow retention policy of max %v backups", backupFile.filename, retentionCount)
-		err := svc.RemoveObject(s3.BucketName, backupFile.filename)
-		if err != nil {
-			logrus.Errorf("Error detected during deletion: %v", err)
-			return err
+	for _, value := range []int{policy.KeepLast, policy.MaxAgeDays, policy.KeepDaily, policy.KeepWeekly, policy.KeepMonthly, policy.MinKeep} {
+		if value < 0 {
+			return fmt.Errorf("retention policy values cannot be negative")
 		}
-		logrus.Infof("Success delete s3 backup file [%s]", backupFile.filename)
+	}
+	if policy.KeepLast == 0 && policy.MaxAgeDays == 0 && policy.KeepDaily == 0 && policy.KeepWeekly == 0 && policy.KeepMonthly == 0 {
+		return fmt.Errorf("retention policy must set at least one of keepLast, maxAgeDays, keepDaily, keepWeekly or keepMonthly")
 	}
 	return nil
 }
@@ -0,0 +1,79 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+)
+
+// backupsToDelete returns the backups that are not kept by any rule of the retention policy, oldest last.
+// A backup is kept if it is:
+// 1. among the newest policy.MinKeep or policy.KeepLast backups,
+// 2. younger than policy.MaxAgeDays,
+// 3. the newest backup of one of the newest policy.KeepDaily days, policy.KeepWeekly ISO weeks or policy.KeepMonthly months
+// that have backups. This is the grandfather-father-son scheme, for example keepDaily: 7, keepWeekly: 4, keepMonthly: 12
+func backupsToDelete(backups []backupInfo, policy v1.RetentionPolicy, now time.Time) []backupInfo {
+	sorted := make([]backupInfo, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].creationTimestamp.After(sorted[j].creationTimestamp)
+	})
+
+	keep := make([]bool, len(sorted))
+	keepNewest := policy.KeepLast
+	if policy.MinKeep > keepNewest {
+		keepNewest = policy.MinKeep
+	}
+	for i := 0; i < len(sorted) && i < keepNewest; i++ {
+		keep[i] = true
+	}
+
+	if policy.MaxAgeDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.MaxAgeDays)
+		for i, b := range sorted {
+			if b.creationTimestamp.After(cutoff) {
+				keep[i] = true
+			}
+		}
+	}
+
+	keepNewestPerPeriod(sorted, keep, policy.KeepDaily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepNewestPerPeriod(sorted, keep, policy.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepNewestPerPeriod(sorted, keep, policy.KeepMonthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	var toDelete []backupInfo
+	for i, b := range sorted {
+		if !keep[i] {
+			toDelete = append(toDelete, b)
+		}
+	}
+	return toDelete
+}
+
+// keepNewestPerPeriod marks the newest backup of each of the newest "count" periods for keeping, backups must be sorted newest first
+func keepNewestPerPeriod(sorted []backupInfo, keep []bool, count int, period func(time.Time) string) {
+	if count <= 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	for i, b := range sorted {
+		p := period(b.creationTimestamp.UTC())
+		if seen[p] {
+			continue
+		}
+		if len(seen) == count {
+			return
+		}
+		seen[p] = true
+		keep[i] = true
+	}
+}
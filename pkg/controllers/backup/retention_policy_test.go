@@ -0,0 +1,244 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newBackupInfo(t *testing.T, filename, timestamp string) backupInfo {
+	creationTimestamp, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		t.Fatalf("invalid timestamp %v: %v", timestamp, err)
+	}
+	return backupInfo{filename: filename, creationTimestamp: creationTimestamp}
+}
+
+func filenames(backups []backupInfo) []string {
+	var names []string
+	for _, b := range backups {
+		names = append(names, b.filename)
+	}
+	return names
+}
+
+func TestBackupsToDelete(t *testing.T) {
+	// a Sunday, in ISO week 2026-W11
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		policy  v1.RetentionPolicy
+		backups [][2]string
+		want    []string
+	}{
+		{
+			name:   "no backups",
+			policy: v1.RetentionPolicy{KeepLast: 2},
+		},
+		{
+			name:   "keepLast keeps the newest backups and deletes the others oldest last",
+			policy: v1.RetentionPolicy{KeepLast: 2},
+			backups: [][2]string{
+				{"c", "2026-03-13T10:00:00Z"},
+				{"a", "2026-03-15T10:00:00Z"},
+				{"d", "2026-03-12T10:00:00Z"},
+				{"b", "2026-03-14T10:00:00Z"},
+			},
+			want: []string{"c", "d"},
+		},
+		{
+			name:   "keepLast keeps every backup when there are fewer",
+			policy: v1.RetentionPolicy{KeepLast: 5},
+			backups: [][2]string{
+				{"a", "2026-03-15T10:00:00Z"},
+				{"b", "2026-03-14T10:00:00Z"},
+			},
+		},
+		{
+			name:   "minKeep keeps the newest backups older than maxAgeDays",
+			policy: v1.RetentionPolicy{MaxAgeDays: 7, MinKeep: 2},
+			backups: [][2]string{
+				{"a", "2026-03-01T10:00:00Z"},
+				{"b", "2026-02-28T10:00:00Z"},
+				{"c", "2026-02-27T10:00:00Z"},
+			},
+			want: []string{"c"},
+		},
+		{
+			name:   "minKeep raises keepLast",
+			policy: v1.RetentionPolicy{KeepLast: 1, MinKeep: 3},
+			backups: [][2]string{
+				{"a", "2026-03-15T10:00:00Z"},
+				{"b", "2026-03-14T10:00:00Z"},
+				{"c", "2026-03-13T10:00:00Z"},
+				{"d", "2026-03-12T10:00:00Z"},
+			},
+			want: []string{"d"},
+		},
+		{
+			name:   "minKeep doesn't limit the backups kept by other rules",
+			policy: v1.RetentionPolicy{MaxAgeDays: 7, MinKeep: 1},
+			backups: [][2]string{
+				{"a", "2026-03-15T10:00:00Z"},
+				{"b", "2026-03-14T10:00:00Z"},
+				{"c", "2026-03-01T10:00:00Z"},
+			},
+			want: []string{"c"},
+		},
+		{
+			name:   "maxAgeDays deletes backups taken at the cutoff or before",
+			policy: v1.RetentionPolicy{MaxAgeDays: 1},
+			backups: [][2]string{
+				{"a", "2026-03-14T12:00:01Z"},
+				{"b", "2026-03-14T12:00:00Z"},
+				{"c", "2026-03-13T12:00:00Z"},
+			},
+			want: []string{"b", "c"},
+		},
+		{
+			name:   "keepDaily keeps the newest backup of each day",
+			policy: v1.RetentionPolicy{KeepDaily: 2},
+			backups: [][2]string{
+				{"a", "2026-03-15T01:00:00Z"},
+				{"b", "2026-03-15T00:00:00Z"},
+				{"c", "2026-03-14T23:59:59Z"},
+				{"d", "2026-03-14T10:00:00Z"},
+				{"e", "2026-03-13T10:00:00Z"},
+			},
+			want: []string{"b", "d", "e"},
+		},
+		{
+			name:   "keepDaily splits days in UTC",
+			policy: v1.RetentionPolicy{KeepDaily: 2},
+			backups: [][2]string{
+				// 2026-03-14T23:30:00Z
+				{"a", "2026-03-15T01:30:00+02:00"},
+				{"b", "2026-03-14T20:00:00Z"},
+				{"c", "2026-03-13T22:30:00Z"},
+			},
+			want: []string{"b"},
+		},
+		{
+			name:   "keepWeekly keeps the newest backup of each ISO week across the end of a year",
+			policy: v1.RetentionPolicy{KeepWeekly: 2},
+			backups: [][2]string{
+				// Sunday, Thursday and Monday of 2026-W01
+				{"a", "2026-01-04T10:00:00Z"},
+				{"b", "2026-01-01T10:00:00Z"},
+				{"c", "2025-12-29T00:00:00Z"},
+				// Sunday and Monday of 2025-W52
+				{"d", "2025-12-28T23:59:59Z"},
+				{"e", "2025-12-22T10:00:00Z"},
+			},
+			want: []string{"b", "c", "e"},
+		},
+		{
+			name:   "keepMonthly keeps the newest backup of each month",
+			policy: v1.RetentionPolicy{KeepMonthly: 2},
+			backups: [][2]string{
+				{"a", "2026-03-01T00:00:00Z"},
+				{"b", "2026-02-28T23:59:59Z"},
+				{"c", "2026-02-01T00:00:00Z"},
+				{"d", "2026-01-31T23:00:00Z"},
+			},
+			want: []string{"c", "d"},
+		},
+		{
+			name:   "periods without backups aren't counted",
+			policy: v1.RetentionPolicy{KeepMonthly: 2},
+			backups: [][2]string{
+				{"a", "2026-03-10T10:00:00Z"},
+				{"b", "2025-11-10T10:00:00Z"},
+				{"c", "2025-11-01T10:00:00Z"},
+				{"d", "2025-06-01T10:00:00Z"},
+			},
+			want: []string{"c", "d"},
+		},
+		{
+			name:   "a backup is kept if any rule keeps it",
+			policy: v1.RetentionPolicy{KeepLast: 1, KeepDaily: 2, KeepWeekly: 2},
+			backups: [][2]string{
+				{"a", "2026-03-15T10:00:00Z"},
+				{"b", "2026-03-15T08:00:00Z"},
+				{"c", "2026-03-14T10:00:00Z"},
+				// Monday of 2026-W11, then Sunday and Monday of 2026-W10
+				{"d", "2026-03-09T10:00:00Z"},
+				{"e", "2026-03-08T10:00:00Z"},
+				{"f", "2026-03-02T10:00:00Z"},
+			},
+			want: []string{"b", "d", "f"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var backups []backupInfo
+			for _, b := range tt.backups {
+				backups = append(backups, newBackupInfo(t, b[0], b[1]))
+			}
+			got := filenames(backupsToDelete(backups, tt.policy, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("backupsToDelete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRetentionPolicy(t *testing.T) {
+	backups := []backupInfo{
+		newBackupInfo(t, "a", "2026-03-15T10:00:00Z"),
+		newBackupInfo(t, "b", "2026-03-14T10:00:00Z"),
+		newBackupInfo(t, "c", "2026-03-13T10:00:00Z"),
+	}
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantRemoved []string
+		wantEvents  []string
+	}{
+		{
+			name:        "deletes the backups not kept",
+			wantRemoved: []string{"b", "c"},
+			wantEvents: []string{
+				"Normal " + eventReasonRetentionDeleted + " Deleted b to follow retention policy",
+				"Normal " + eventReasonRetentionDeleted + " Deleted c to follow retention policy",
+			},
+		},
+		{
+			name:       "dry run only lists the backups it would delete",
+			dryRun:     true,
+			wantEvents: []string{"Normal " + eventReasonRetentionDryRun + " Retention policy would delete 2 backups"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			h := &handler{recorder: recorder}
+			var removed []string
+			deleted, err := h.applyRetentionPolicy(&v1.Backup{}, v1.RetentionPolicy{KeepLast: 1, DryRun: tt.dryRun}, backups,
+				func(file backupInfo) error {
+					removed = append(removed, file.filename)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("applyRetentionPolicy() error = %v", err)
+			}
+			if want := []string{"b", "c"}; !reflect.DeepEqual(deleted, want) {
+				t.Errorf("applyRetentionPolicy() = %v, want %v", deleted, want)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed %v, want %v", removed, tt.wantRemoved)
+			}
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Errorf("events %v, want %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseFilenameTimestamp(t *testing.T) {
+	tests := []struct {
+		ts      string
+		want    string
+		wantErr bool
+	}{
+		{ts: "2020-09-26T12-49-34Z", want: "2020-09-26T12:49:34Z"},
+		{ts: "2020-09-26T12-49-34-07-00", want: "2020-09-26T12:49:34-07:00"},
+		{ts: "2020-09-26T12-49-34+05-30", want: "2020-09-26T12:49:34+05:30"},
+		{ts: "2020-09-26", wantErr: true},
+		{ts: "2020-09-26T12-49", wantErr: true},
+		{ts: "2020-09-26T12-49-34", wantErr: true},
+		{ts: "2020-09-26T12-49-34+05", wantErr: true},
+		{ts: "2020-13-26T12-49-34Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ts, func(t *testing.T) {
+			got, err := parseFilenameTimestamp(tt.ts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFilenameTimestamp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want, _ := time.Parse(time.RFC3339, tt.want)
+			if !got.Equal(want) {
+				t.Errorf("parseFilenameTimestamp() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestBackupTimestamp(t *testing.T) {
+	fallback := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filename string
+		want     time.Time
+	}{
+		{
+			name:     "backup file",
+			filename: "daily-uid-2026-03-14T10-00-00Z.tar.gz",
+			want:     time.Date(2026, time.March, 14, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "encrypted backup file",
+			filename: "daily-uid-2026-03-14T12-00-00+02-00.tar.gz.enc",
+			want:     time.Date(2026, time.March, 14, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "filename without a timestamp falls back",
+			filename: "daily-uid-latest.tar.gz",
+			want:     fallback,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handler{kubeSystemNS: "uid"}
+			got := h.backupTimestamp(&v1.Backup{ObjectMeta: k8sv1.ObjectMeta{Name: "daily"}}, tt.filename, fallback)
+			if !got.Equal(tt.want) {
+				t.Errorf("backupTimestamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *v1.RetentionPolicy
+		wantErr bool
+	}{
+		{name: "no policy"},
+		{name: "keepLast", policy: &v1.RetentionPolicy{KeepLast: 3}},
+		{name: "GFS", policy: &v1.RetentionPolicy{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12, MinKeep: 1}},
+		{name: "only minKeep", policy: &v1.RetentionPolicy{MinKeep: 3}, wantErr: true},
+		{name: "only dryRun", policy: &v1.RetentionPolicy{DryRun: true}, wantErr: true},
+		{name: "negative value", policy: &v1.RetentionPolicy{KeepLast: 3, MaxAgeDays: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRetentionPolicy(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("validateRetentionPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
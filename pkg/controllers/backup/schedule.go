@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"hash/fnv"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TriggerAnnotation takes a backup immediately whenever its value changes, for example to the current timestamp.
+// The schedule of a recurring backup is not changed by triggered backups
+const TriggerAnnotation = "resources.cattle.io/trigger"
+
+// pendingTrigger returns the value of the Backup's trigger annotation if no backup was taken for it yet
+func pendingTrigger(backup *v1.Backup) string {
+	trigger := backup.Annotations[TriggerAnnotation]
+	if trigger == backup.Status.LastTrigger {
+		return ""
+	}
+	return trigger
+}
+
+// missedSnapshot returns true if it is too late to take the snapshot scheduled at the given time.
+// Like for CronJobs, a snapshot is only missed if the Backup has startingDeadlineSeconds, otherwise a late snapshot is taken right away
+func missedSnapshot(backup *v1.Backup, scheduled, now time.Time) bool {
+	if backup.Spec.StartingDeadlineSeconds == nil {
+		return false
+	}
+	deadline := scheduled.Add(time.Duration(*backup.Spec.StartingDeadlineSeconds) * time.Second)
+	return now.After(deadline)
+}
+
+// skipMissedSnapshot records the missed snapshot and schedules the next one, without taking a backup
+func (h *handler) skipMissedSnapshot(backup *v1.Backup, missed time.Time) (*v1.Backup, error) {
+	nextBackupAt, err := h.nextSnapshotTime(backup, time.Now())
+	if err != nil {
+		return h.setReconcilingCondition(backup, err)
+	}
+	logrus.Infof("Missed backup scheduled for %v for backup CR %v, the starting deadline of %vs has passed. Next backup is scheduled for %v",
+		missed.Format(time.RFC3339), backup.Name, *backup.Spec.StartingDeadlineSeconds, nextBackupAt.Format(time.RFC3339))
+	h.recorder.Eventf(backup, corev1.EventTypeWarning, eventReasonMissed, "Missed backup scheduled for %v, next backup is scheduled for %v",
+		missed.Format(time.RFC3339), nextBackupAt.Format(time.RFC3339))
+	backup.Status.MissedSnapshotAt = missed.Format(time.RFC3339)
+	backup.Status.NextSnapshotAt = nextBackupAt.Format(time.RFC3339)
+	h.setScheduleStatus(backup)
+	backup.Status.ObservedGeneration = backup.Generation
+	h.backups.EnqueueAfter(backup.Name, nextBackupAt.Sub(time.Now()))
+	return h.backups.UpdateStatus(backup)
+}
+
+// suspendedBackup only updates the observedGeneration of a suspended Backup, its scheduled backups are taken again once it's resumed,
+// following startingDeadlineSeconds for a snapshot that was due while it was suspended
+func (h *handler) suspendedBackup(backup *v1.Backup) (*v1.Backup, error) {
+	logrus.Infof("Backup CR %v is suspended, not taking any backups", backup.Name)
+	if backup.Generation != backup.Status.ObservedGeneration {
+		backup.Status.ObservedGeneration = backup.Generation
+		return h.backups.UpdateStatus(backup)
+	}
+	return backup, nil
+}
+
+// nextSnapshotTime returns the time of the Backup's next scheduled snapshot after now. The schedule is interpreted in the Backup's
+// time zone, and the snapshot is delayed by the Backup's jitter
+func (h *handler) nextSnapshotTime(backup *v1.Backup, now time.Time) (time.Time, error) {
+	cronSchedule, err := cron.ParseStandard(backup.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	location, err := scheduleLocation(backup)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cronSchedule.Next(now.In(location)).Add(h.scheduleJitter(backup)), nil
+}
+
+// scheduleLocation returns the Backup's time zone, or the operator's local time zone if it has none
+func scheduleLocation(backup *v1.Backup) (*time.Location, error) {
+	if backup.Spec.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(backup.Spec.TimeZone)
+}
+
+// scheduleJitter returns a delay within the Backup's jitter window, derived from the cluster's kube-system UID and the Backup's name.
+// It is the same for every snapshot of a Backup, but spreads the backups of many clusters on the same schedule over the window
+func (h *handler) scheduleJitter(backup *v1.Backup) time.Duration {
+	if backup.Spec.JitterWindowSeconds <= 0 {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(h.kubeSystemNS + "/" + backup.Name))
+	return time.Duration(int64(hash.Sum32())%backup.Spec.JitterWindowSeconds) * time.Second
+}
+
+// setScheduleStatus shows the time zone and jitter used for computing nextSnapshotAt
+func (h *handler) setScheduleStatus(backup *v1.Backup) {
+	if location, err := scheduleLocation(backup); err == nil {
+		backup.Status.ScheduleTimeZone = location.String()
+	}
+	backup.Status.ScheduleJitter = h.scheduleJitter(backup).String()
+}
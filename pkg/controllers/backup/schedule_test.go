@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newScheduledBackup(name, schedule, timeZone string, jitterWindowSeconds int64) *v1.Backup {
+	return &v1.Backup{
+		ObjectMeta: k8sv1.ObjectMeta{Name: name},
+		Spec:       v1.BackupSpec{Schedule: schedule, TimeZone: timeZone, JitterWindowSeconds: jitterWindowSeconds},
+	}
+}
+
+func TestNextSnapshotTime(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		schedule string
+		timeZone string
+		want     time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "UTC",
+			schedule: "0 2 * * *",
+			timeZone: "UTC",
+			want:     time.Date(2026, time.March, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "later today in the time zone",
+			schedule: "0 14 * * *",
+			timeZone: "Asia/Kolkata",
+			want:     time.Date(2026, time.March, 16, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "time zone behind UTC",
+			schedule: "0 2 * * *",
+			timeZone: "America/New_York",
+			// EDT, daylight saving time started on March 8
+			want: time.Date(2026, time.March, 16, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of week in the time zone",
+			schedule: "30 0 * * MON",
+			timeZone: "Pacific/Auckland",
+			// Sunday 12:00 UTC is Monday 01:00 in Auckland, so the next Monday 00:30 there is a week later
+			want: time.Date(2026, time.March, 22, 11, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "invalid schedule",
+			schedule: "0 25 * * *",
+			timeZone: "UTC",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid time zone",
+			schedule: "0 2 * * *",
+			timeZone: "Mars/Olympus_Mons",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handler{kubeSystemNS: "uid"}
+			got, err := h.nextSnapshotTime(newScheduledBackup("daily", tt.schedule, tt.timeZone, 0), now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nextSnapshotTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("nextSnapshotTime() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleJitter(t *testing.T) {
+	tests := []struct {
+		name                string
+		kubeSystemNS        string
+		backupName          string
+		jitterWindowSeconds int64
+	}{
+		{name: "no window", kubeSystemNS: "uid", backupName: "daily"},
+		{name: "one second window", kubeSystemNS: "uid", backupName: "daily", jitterWindowSeconds: 1},
+		{name: "window", kubeSystemNS: "uid", backupName: "daily", jitterWindowSeconds: 3600},
+		{name: "other cluster", kubeSystemNS: "other-uid", backupName: "daily", jitterWindowSeconds: 3600},
+		{name: "other backup", kubeSystemNS: "uid", backupName: "hourly", jitterWindowSeconds: 3600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &handler{kubeSystemNS: tt.kubeSystemNS}
+			backup := newScheduledBackup(tt.backupName, "0 2 * * *", "UTC", tt.jitterWindowSeconds)
+			jitter := h.scheduleJitter(backup)
+			window := time.Duration(tt.jitterWindowSeconds) * time.Second
+			if jitter < 0 || (window > 0 && jitter >= window) || (window == 0 && jitter != 0) {
+				t.Errorf("scheduleJitter() = %v, want within [0, %vs)", jitter, tt.jitterWindowSeconds)
+			}
+			if jitter%time.Second != 0 {
+				t.Errorf("scheduleJitter() = %v, want whole seconds", jitter)
+			}
+			if again := h.scheduleJitter(backup); again != jitter {
+				t.Errorf("scheduleJitter() = %v, then %v for the same backup", jitter, again)
+			}
+
+			now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+			next, err := h.nextSnapshotTime(backup, now)
+			if err != nil {
+				t.Fatalf("nextSnapshotTime() error = %v", err)
+			}
+			if want := time.Date(2026, time.March, 16, 2, 0, 0, 0, time.UTC).Add(jitter); !next.Equal(want) {
+				t.Errorf("nextSnapshotTime() = %v, want %v", next, want)
+			}
+		})
+	}
+
+	h := &handler{kubeSystemNS: "uid"}
+	if h.scheduleJitter(newScheduledBackup("daily", "", "", 3600)) == h.scheduleJitter(newScheduledBackup("hourly", "", "", 3600)) {
+		t.Errorf("scheduleJitter() is the same for different backups")
+	}
+}
@@ -0,0 +1,336 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v6"
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/objectstore"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// backupStore lists and removes backup files, and reads and writes their metadata files in a storage location.
+// Filenames are relative to the storage location, and may contain folders
+type backupStore interface {
+	// list returns the files in the storage location and its folders for which match returns true, match is called with the base name of the file
+	list(match func(filename string) bool) ([]backupInfo, error)
+	remove(file backupInfo) error
+	readMetadata(filename string) (*util.BackupMetadata, error)
+	writeMetadata(metadata *util.BackupMetadata) error
+	// readTags returns the object tags of the file, or none if the storage location doesn't support tags
+	readTags(filename string) (map[string]string, error)
+	// fetch returns the path of a local copy of the file, and a function removing it once it has been read
+	fetch(ctx context.Context, filename string) (string, func(), error)
+	String() string
+}
+
+type mountPathStore struct {
+	path string
+}
+
+type s3Store struct {
+	client      *minio.Client
+	objectStore *v1.S3ObjectStore
+}
+
+// storeForBackup returns the store for the Backup's storage location, or the operator's default location if it has none
+func (h *handler) storeForBackup(backup *v1.Backup) (backupStore, error) {
+	if backup.Spec.StorageLocation == nil {
+		return h.defaultStore()
+	}
+	if backup.Spec.StorageLocation.S3 != nil {
+		return h.newS3Store(backup.Spec.StorageLocation.S3)
+	}
+	return nil, nil
+}
+
+// defaultStore returns the store for the storage location the operator is configured with, or nil if there is none
+func (h *handler) defaultStore() (backupStore, error) {
+	if h.defaultBackupMountPath != "" {
+		return &mountPathStore{path: h.defaultBackupMountPath}, nil
+	} else if h.defaultS3BackupLocation != nil {
+		return h.newS3Store(h.defaultS3BackupLocation)
+	}
+	return nil, nil
+}
+
+func (h *handler) newS3Store(objectStore *v1.S3ObjectStore) (backupStore, error) {
+	s3Client, err := objectstore.GetS3Client(h.ctx, objectStore, h.storageDynamicClient)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Store{client: s3Client, objectStore: objectStore}, nil
+}
+
+// backupFileMatcher matches the files created for the Backup CR by generateBackupFilename, with any of the given suffixes
+func (h *handler) backupFileMatcher(backup *v1.Backup, suffixes ...string) func(string) bool {
+	prefix := fmt.Sprintf("%s-%s-", backup.Name, h.kubeSystemNS)
+	return func(filename string) bool {
+		if !strings.HasPrefix(filename, prefix) {
+			return false
+		}
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(filename, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (m *mountPathStore) list(match func(filename string) bool) ([]backupInfo, error) {
+	logrus.Infof("Finding backup files in %v", m.path)
+	var backupFiles []backupInfo
+	err := filepath.Walk(m.path, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() || !match(fileInfo.Name()) {
+			return nil
+		}
+		relativePath, err := filepath.Rel(m.path, filePath)
+		if err != nil {
+			return err
+		}
+		backupFiles = append(backupFiles, backupInfo{
+			filename:          filepath.ToSlash(relativePath),
+			creationTimestamp: fileInfo.ModTime(),
+		})
+		return nil
+	})
+	return backupFiles, err
+}
+
+func (m *mountPathStore) remove(file backupInfo) error {
+	return os.Remove(filepath.Join(m.path, file.filename))
+}
+
+func (m *mountPathStore) readMetadata(filename string) (*util.BackupMetadata, error) {
+	data, err := ioutil.ReadFile(filepath.Join(m.path, filename))
+	if err != nil {
+		return nil, err
+	}
+	return util.ParseBackupMetadata(data)
+}
+
+func (m *mountPathStore) writeMetadata(metadata *util.BackupMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(m.path, util.MetadataFilename(metadata.Filename)), data, 0600)
+}
+
+func (m *mountPathStore) readTags(filename string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (m *mountPathStore) fetch(_ context.Context, filename string) (string, func(), error) {
+	return filepath.Join(m.path, filename), func() {}, nil
+}
+
+func (m *mountPathStore) String() string {
+	return m.path
+}
+
+func (s *s3Store) list(match func(filename string) bool) ([]backupInfo, error) {
+	// Create a done channel to control 'ListObjects' go routine.
+	doneCh := make(chan struct{})
+
+	// Indicate to our routine to exit cleanly upon return.
+	defer close(doneCh)
+
+	prefix := ""
+	if len(s.objectStore.Folder) != 0 {
+		prefix = s.objectStore.Folder + "/"
+	}
+	// Recurse will show us the files in the folder, and in the date folders created by filename templates
+	objectCh := s.client.ListObjects(s.objectStore.BucketName, prefix, true, doneCh)
+	var backupFiles []backupInfo
+	for object := range objectCh {
+		if object.Err != nil {
+			logrus.Error("error to fetch s3 file:", object.Err)
+			return nil, object.Err
+		}
+		// only parse backup file names that matches backup format
+		if match(path.Base(object.Key)) {
+			backupFiles = append(backupFiles, backupInfo{
+				filename:          strings.TrimPrefix(object.Key, prefix),
+				creationTimestamp: object.LastModified,
+			})
+		}
+	}
+	return backupFiles, nil
+}
+
+func (s *s3Store) remove(file backupInfo) error {
+	if err := s.client.RemoveObject(s.objectStore.BucketName, s.objectKey(file.filename)); err != nil {
+		logrus.Errorf("Error detected during deletion: %v", err)
+		return err
+	}
+	logrus.Infof("Success delete s3 backup file [%s]", file.filename)
+	return nil
+}
+
+func (s *s3Store) readMetadata(filename string) (*util.BackupMetadata, error) {
+	object, err := s.client.GetObject(s.objectStore.BucketName, s.objectKey(filename), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	data, err := ioutil.ReadAll(object)
+	if err != nil {
+		return nil, err
+	}
+	return util.ParseBackupMetadata(data)
+}
+
+func (s *s3Store) writeMetadata(metadata *util.BackupMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(s.objectStore.BucketName, s.objectKey(util.MetadataFilename(metadata.Filename)), bytes.NewReader(data),
+		int64(len(data)), minio.PutObjectOptions{ContentType: "application/json"})
+	return err
+}
+
+func (s *s3Store) readTags(filename string) (map[string]string, error) {
+	return objectstore.GetObjectTags(s.client, s.objectStore.BucketName, s.objectKey(filename))
+}
+
+func (s *s3Store) fetch(ctx context.Context, filename string) (string, func(), error) {
+	object, err := s.client.GetObjectWithContext(ctx, s.objectStore.BucketName, s.objectKey(filename), minio.GetObjectOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+	defer object.Close()
+	localFile, err := ioutil.TempFile("", path.Base(filename))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		os.Remove(localFile.Name())
+	}
+	_, err = io.Copy(localFile, object)
+	if closeErr := localFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("error downloading %v: %v", filename, err)
+	}
+	return localFile.Name(), cleanup, nil
+}
+
+func (s *s3Store) objectKey(filename string) string {
+	if s.objectStore.Folder != "" {
+		return s.objectStore.Folder + "/" + filename
+	}
+	return filename
+}
+
+func (s *s3Store) String() string {
+	if s.objectStore.Folder != "" {
+		return fmt.Sprintf("s3 bucket %v/%v", s.objectStore.BucketName, s.objectStore.Folder)
+	}
+	return fmt.Sprintf("s3 bucket %v", s.objectStore.BucketName)
+}
+
+// backupFiles returns the files with any of the given suffixes that were created for the Backup CR in the store, with the time they
+// were taken as creationTimestamp. Files are attributed to the Backup CR by their metadata file, files created before metadata files
+// were written are matched by the filename generateBackupFilename used to produce for them
+func (h *handler) backupFiles(store backupStore, backup *v1.Backup, suffixes ...string) ([]backupInfo, error) {
+	files, err := listWithMetadata(store, suffixes...)
+	if err != nil {
+		return nil, err
+	}
+	legacyMatch := h.backupFileMatcher(backup, suffixes...)
+	var backupFiles []backupInfo
+	for _, file := range files {
+		if file.metadata == nil {
+			if legacyMatch(path.Base(file.filename)) {
+				file.creationTimestamp = h.backupTimestamp(backup, path.Base(file.filename), file.creationTimestamp)
+				backupFiles = append(backupFiles, file)
+			}
+			continue
+		}
+		if file.metadata.BackupName != backup.Name || file.metadata.ClusterUID != h.kubeSystemNS {
+			continue
+		}
+		if createdAt, err := time.Parse(time.RFC3339, file.metadata.CreatedAt); err == nil {
+			file.creationTimestamp = createdAt
+		}
+		backupFiles = append(backupFiles, file)
+	}
+	return backupFiles, nil
+}
+
+// listWithMetadata returns the files with any of the given suffixes in the store, along with their metadata if they have a metadata
+// file or were tagged with it
+func listWithMetadata(store backupStore, suffixes ...string) ([]backupInfo, error) {
+	files, err := store.list(func(filename string) bool {
+		return util.IsMetadataFilename(filename) || hasAnySuffix(filename, suffixes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	hasMetadata := make(map[string]bool)
+	for _, file := range files {
+		if util.IsMetadataFilename(file.filename) {
+			hasMetadata[strings.TrimSuffix(file.filename, util.MetadataFileSuffix)] = true
+		}
+	}
+	var backupFiles []backupInfo
+	for _, file := range files {
+		if util.IsMetadataFilename(file.filename) {
+			continue
+		}
+		if hasMetadata[file.filename] {
+			if file.metadata, err = store.readMetadata(util.MetadataFilename(file.filename)); err != nil {
+				return nil, fmt.Errorf("error reading metadata of backup file %v: %v", file.filename, err)
+			}
+		} else {
+			// the metadata file may have failed to be written after uploading, the object tags carry the same information
+			if tags, err := store.readTags(file.filename); err != nil {
+				// not every S3 compatible object store supports tags, fall back to the filename
+				logrus.Warnf("Error reading tags of backup file %v: %v", file.filename, err)
+			} else {
+				file.metadata = util.BackupMetadataFromTags(file.filename, tags)
+			}
+		}
+		backupFiles = append(backupFiles, file)
+	}
+	return backupFiles, nil
+}
+
+// removeWithMetadata removes the backup file, and its metadata file if it has one
+func removeWithMetadata(store backupStore, file backupInfo) error {
+	if err := store.remove(file); err != nil {
+		return err
+	}
+	if file.metadata == nil {
+		return nil
+	}
+	return store.remove(backupInfo{filename: util.MetadataFilename(file.filename)})
+}
+
+func hasAnySuffix(filename string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(filename, suffix) {
+			return true
+		}
+	}
+	return false
+}
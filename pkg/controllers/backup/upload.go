@@ -3,6 +3,7 @@ package backup
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,35 +13,46 @@ import (
 
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	"github.com/rancher/backup-restore-operator/pkg/objectstore"
+	"github.com/rancher/backup-restore-operator/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
-func (h *handler) uploadToS3(backup *v1.Backup, objectStore *v1.S3ObjectStore, tmpBackupPath, gzipFile string) error {
+func (h *handler) uploadToS3(ctx context.Context, backup *v1.Backup, objectStore *v1.S3ObjectStore, tmpBackupPath, gzipFile string,
+	metadata *util.BackupMetadata) (int64, error) {
 	tmpBackupGzipFilepath, err := ioutil.TempDir("", "uploadpath")
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if objectStore.Folder != "" {
 		if err := os.Mkdir(filepath.Join(tmpBackupGzipFilepath, objectStore.Folder), os.ModePerm); err != nil {
-			return removeTempUploadDir(tmpBackupGzipFilepath, err)
+			return 0, removeTempUploadDir(tmpBackupGzipFilepath, err)
 		}
 		gzipFile = fmt.Sprintf("%s/%s", objectStore.Folder, gzipFile)
 	}
 	if err := CreateTarAndGzip(tmpBackupPath, tmpBackupGzipFilepath, gzipFile, backup.Name); err != nil {
-		return removeTempUploadDir(tmpBackupGzipFilepath, err)
+		return 0, removeTempUploadDir(tmpBackupGzipFilepath, err)
 	}
-	s3Client, err := objectstore.GetS3Client(h.ctx, objectStore, h.dynamicClient)
+	size, err := fileSize(filepath.Join(tmpBackupGzipFilepath, gzipFile))
 	if err != nil {
-		return removeTempUploadDir(tmpBackupGzipFilepath, err)
+		return 0, removeTempUploadDir(tmpBackupGzipFilepath, err)
 	}
-	if err := objectstore.UploadBackupFile(s3Client, objectStore.BucketName, gzipFile, filepath.Join(tmpBackupGzipFilepath, gzipFile)); err != nil {
-		return removeTempUploadDir(tmpBackupGzipFilepath, err)
+	s3Client, err := objectstore.GetS3Client(ctx, objectStore, h.storageDynamicClient)
+	if err != nil {
+		return 0, removeTempUploadDir(tmpBackupGzipFilepath, err)
+	}
+	if err := objectstore.UploadBackupFile(ctx, s3Client, objectStore.BucketName, gzipFile, filepath.Join(tmpBackupGzipFilepath, gzipFile),
+		metadata.UserMetadata(), metadata.ObjectTags()); err != nil {
+		return 0, removeTempUploadDir(tmpBackupGzipFilepath, err)
 	}
-	return os.RemoveAll(tmpBackupGzipFilepath)
+	return size, os.RemoveAll(tmpBackupGzipFilepath)
 }
 
 func CreateTarAndGzip(backupPath, targetGzipPath, targetGzipFile, backupCRName string) error {
 	logrus.Infof("Compressing backup CR %v", backupCRName)
+	// the filename template can put backup files in folders
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(targetGzipPath, targetGzipFile)), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating folder for backup tar gzip file: %v", err)
+	}
 	gzipFile, err := os.Create(filepath.Join(targetGzipPath, targetGzipFile))
 	if err != nil {
 		return fmt.Errorf("error creating backup tar gzip file: %v", err)
@@ -0,0 +1,237 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/storage/value"
+	"k8s.io/client-go/util/retry"
+)
+
+// defaultScrubTimeout bounds a scrub of a Backup without timeoutSeconds
+const defaultScrubTimeout = time.Hour
+
+// verifyBackupFile reads the backup file back from the store the way a restore would, and records the result
+func (h *handler) verifyBackupFile(ctx context.Context, backup *v1.Backup, store backupStore, filename string,
+	transformerMap map[schema.GroupResource]value.Transformer) *v1.BackupVerification {
+	logrus.Infof("Verifying backup file %v of backup CR %v in %v", filename, backup.Name, store)
+	verification := &v1.BackupVerification{
+		Filename:   filename,
+		VerifiedAt: time.Now().Format(time.RFC3339),
+		Result:     v1.BackupResultSucceeded,
+	}
+	objects, err := readBackupFile(ctx, store, filename, transformerMap)
+	if err != nil {
+		logrus.Errorf("Error verifying backup file %v of backup CR %v: %v", filename, backup.Name, err)
+		verification.Result = v1.BackupResultFailed
+		verification.Error = err.Error()
+	}
+	verification.Objects = objects
+	metrics.BackupVerified(backup.Name, err == nil)
+	return verification
+}
+
+func readBackupFile(ctx context.Context, store backupStore, filename string,
+	transformerMap map[schema.GroupResource]value.Transformer) (int, error) {
+	localPath, cleanup, err := store.fetch(ctx, filename)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	return util.VerifyBackupFile(localPath, transformerMap)
+}
+
+// verifyAfterUpload verifies the backup file that was just stored, the result is recorded in the Backup's status with the completed run
+func (h *handler) verifyAfterUpload(ctx context.Context, backup *v1.Backup, gzipFile string, transformerMap map[schema.GroupResource]value.Transformer,
+	run *backupRun) {
+	store, err := h.storeForBackup(backup)
+	if err != nil || store == nil {
+		if err == nil {
+			err = fmt.Errorf("no storage location to read %v back from", gzipFile)
+		}
+		run.verification = &v1.BackupVerification{
+			Filename:   gzipFile,
+			VerifiedAt: time.Now().Format(time.RFC3339),
+			Result:     v1.BackupResultFailed,
+			Error:      err.Error(),
+		}
+		metrics.BackupVerified(backup.Name, false)
+	} else {
+		run.verification = h.verifyBackupFile(ctx, backup, store, gzipFile, transformerMap)
+	}
+	if run.verification.Result == v1.BackupResultFailed {
+		h.recorder.Eventf(backup, corev1.EventTypeWarning, eventReasonVerificationFailed, "Verifying %v failed: %v", gzipFile, run.verification.Error)
+		run.logf("Verifying %v failed: %v", gzipFile, run.verification.Error)
+		return
+	}
+	h.recorder.Eventf(backup, corev1.EventTypeNormal, eventReasonVerified, "Verified %v, read back %v objects", gzipFile, run.verification.Objects)
+	run.logf("Verified %v, read back %v objects", gzipFile, run.verification.Objects)
+}
+
+// setVerifiedCondition sets the Verified condition from the result of the latest verification
+func setVerifiedCondition(backup *v1.Backup, verified bool, message string) {
+	condition.Cond(v1.BackupConditionVerified).SetStatusBool(backup, verified)
+	condition.Cond(v1.BackupConditionVerified).Message(backup, message)
+}
+
+// OnBackupScrub verifies every backup file of the Backup still in its storage location on the Backup's scrubSchedule
+func (h *handler) OnBackupScrub(_ string, backup *v1.Backup) (*v1.Backup, error) {
+	if backup == nil || backup.DeletionTimestamp != nil || backup.Spec.ScrubSchedule == "" {
+		return backup, nil
+	}
+	// an invalid schedule or time zone is reported by OnBackupChange
+	cronSchedule, err := cron.ParseStandard(backup.Spec.ScrubSchedule)
+	if err != nil {
+		return backup, nil
+	}
+	location, err := scheduleLocation(backup)
+	if err != nil {
+		return backup, nil
+	}
+	lastScrub := backup.CreationTimestamp.Time
+	if backup.Status.Scrub != nil && backup.Status.Scrub.LastScrubAt != "" {
+		if lastScrub, err = time.Parse(time.RFC3339, backup.Status.Scrub.LastScrubAt); err != nil {
+			lastScrub = backup.CreationTimestamp.Time
+		}
+	}
+	now := time.Now()
+	if nextScrub := cronSchedule.Next(lastScrub.In(location)); now.Before(nextScrub) {
+		h.backups.EnqueueAfter(backup.Name, nextScrub.Sub(now))
+		if backup.Status.Scrub != nil && backup.Status.Scrub.NextScrubAt == nextScrub.Format(time.RFC3339) {
+			return backup, nil
+		}
+		return h.updateScrubStatus(backup, func(scrub *v1.BackupScrubStatus) {
+			scrub.NextScrubAt = nextScrub.Format(time.RFC3339)
+		}, nil)
+	}
+
+	ctx, cancel := h.scrubContext(backup)
+	scrub, err := h.scrubBackupFiles(ctx, backup)
+	cancel()
+	nextScrub := cronSchedule.Next(now.In(location))
+	h.backups.EnqueueAfter(backup.Name, nextScrub.Sub(now))
+	if err != nil {
+		// the Backup's conditions are about its backups, a failed scrub is only recorded in its scrub status and tried again on schedule
+		logrus.Errorf("Error scrubbing backup files of backup CR %v: %v", backup.Name, err)
+		h.recorder.Eventf(backup, corev1.EventTypeWarning, eventReasonVerificationFailed, "Error scrubbing backup files: %v", err)
+		return h.updateScrubStatus(backup, func(status *v1.BackupScrubStatus) {
+			if scrub != nil {
+				*status = *scrub
+			}
+			status.LastScrubAt = now.Format(time.RFC3339)
+			status.NextScrubAt = nextScrub.Format(time.RFC3339)
+			status.Error = fmt.Sprintf("error scrubbing backup files: %v", err)
+		}, nil)
+	}
+	scrub.LastScrubAt = now.Format(time.RFC3339)
+	scrub.NextScrubAt = nextScrub.Format(time.RFC3339)
+	message := fmt.Sprintf("Scrub verified %v backup files", scrub.VerifiedFiles)
+	if len(scrub.FailedFiles) > 0 {
+		message = fmt.Sprintf("Scrub found %v of %v backup files failing verification", len(scrub.FailedFiles), scrub.VerifiedFiles+len(scrub.FailedFiles))
+		h.recorder.Event(backup, corev1.EventTypeWarning, eventReasonVerificationFailed, message)
+	}
+	return h.updateScrubStatus(backup, func(status *v1.BackupScrubStatus) {
+		*status = *scrub
+	}, func(updBackup *v1.Backup) {
+		setVerifiedCondition(updBackup, len(scrub.FailedFiles) == 0, message)
+	})
+}
+
+// scrubContext returns the context for scrubbing the backup files of a Backup, which is done after the Backup's timeoutSeconds or
+// defaultScrubTimeout, so that a slow storage location doesn't hold one of the controller's workers indefinitely
+func (h *handler) scrubContext(backup *v1.Backup) (context.Context, context.CancelFunc) {
+	timeout := defaultScrubTimeout
+	if backup.Spec.TimeoutSeconds > 0 {
+		timeout = time.Duration(backup.Spec.TimeoutSeconds) * time.Second
+	}
+	return context.WithTimeout(h.ctx, timeout)
+}
+
+// scrubBackupFiles verifies all backup files of the Backup in its storage location. If the context is done before all files are
+// verified, the results so far are returned along with the error
+func (h *handler) scrubBackupFiles(ctx context.Context, backup *v1.Backup) (*v1.BackupScrubStatus, error) {
+	store, err := h.storeForBackup(backup)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, fmt.Errorf("no storage location to read backup files from")
+	}
+	files, err := h.backupFiles(store, backup, ".tar.gz", ".tar.gz.enc")
+	if err != nil {
+		return nil, err
+	}
+	var transformerMap map[schema.GroupResource]value.Transformer
+	if backup.Spec.EncryptionConfigSecretName != "" {
+		transformerMap, err = util.GetEncryptionTransformers(backup.Spec.EncryptionConfigSecretName, backup.Spec.RestrictToNamespace, h.secrets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	logrus.Infof("Scrubbing %v backup files of backup CR %v in %v", len(files), backup.Name, store)
+	scrub := &v1.BackupScrubStatus{}
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return scrub, fmt.Errorf("stopped after verifying %v of %v backup files: %v", i, len(files), err)
+		}
+		var verification *v1.BackupVerification
+		encrypted := strings.HasSuffix(file.filename, ".enc") || (file.metadata != nil && file.metadata.Encrypted)
+		switch {
+		case encrypted && transformerMap == nil:
+			verification = &v1.BackupVerification{
+				Filename:   file.filename,
+				VerifiedAt: time.Now().Format(time.RFC3339),
+				Result:     v1.BackupResultFailed,
+				Error:      "backup file is encrypted, but the Backup has no encryptionConfigSecretName to decrypt it with",
+			}
+			metrics.BackupVerified(backup.Name, false)
+		case encrypted:
+			verification = h.verifyBackupFile(ctx, backup, store, file.filename, transformerMap)
+		default:
+			verification = h.verifyBackupFile(ctx, backup, store, file.filename, nil)
+		}
+		if err := ctx.Err(); err != nil {
+			// the file isn't known to be corrupt if reading it was interrupted
+			return scrub, fmt.Errorf("stopped after verifying %v of %v backup files: %v", i, len(files), err)
+		}
+		if verification.Result == v1.BackupResultFailed {
+			scrub.FailedFiles = append(scrub.FailedFiles, *verification)
+			continue
+		}
+		scrub.VerifiedFiles++
+	}
+	return scrub, nil
+}
+
+func (h *handler) updateScrubStatus(backup *v1.Backup, updateScrub func(*v1.BackupScrubStatus), updateBackup func(*v1.Backup)) (*v1.Backup, error) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updBackup, err := h.backups.Get(backup.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if updBackup.Status.Scrub == nil {
+			updBackup.Status.Scrub = &v1.BackupScrubStatus{}
+		}
+		updateScrub(updBackup.Status.Scrub)
+		if updateBackup != nil {
+			updateBackup(updBackup)
+		}
+		updBackup, err = h.backups.UpdateStatus(updBackup)
+		if err == nil {
+			backup = updBackup
+		}
+		return err
+	})
+	return backup, err
+}
@@ -0,0 +1,204 @@
+package namespaced
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/controllers/backup"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+func (h *handler) OnNamespacedBackupChange(_ string, namespacedBackup *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	if namespacedBackup == nil || namespacedBackup.DeletionTimestamp != nil {
+		return namespacedBackup, nil
+	}
+	if err := checkServiceAccountName(namespacedBackup.Spec.ServiceAccountName); err != nil {
+		// stop the schedule of a Backup created before serviceAccountName was required
+		if suspendErr := h.suspendBackup(childName(namespacedBackup.Namespace, namespacedBackup.Name), namespacedBackup.Namespace); suspendErr != nil {
+			return h.setNamespacedBackupReconcilingCondition(namespacedBackup, suspendErr)
+		}
+		return h.setNamespacedBackupReconcilingCondition(namespacedBackup, err)
+	}
+	resourceSelectors, err := confinedResourceSelectors(namespacedBackup.Spec.ResourceSelectors, namespacedBackup.Namespace)
+	if err != nil {
+		return h.setNamespacedBackupReconcilingCondition(namespacedBackup, err)
+	}
+	storageLocation, err := confinedStorageLocation(namespacedBackup.Spec.StorageLocation, namespacedBackup.Namespace)
+	if err != nil {
+		return h.setNamespacedBackupReconcilingCondition(namespacedBackup, err)
+	}
+
+	resourceSet := &v1.ResourceSet{
+		ObjectMeta:        childObjectMeta(namespacedBackup.Namespace, namespacedBackup.Name),
+		ResourceSelectors: resourceSelectors,
+	}
+	if err := h.applyResourceSet(resourceSet, namespacedBackup.Namespace); err != nil {
+		return h.setNamespacedBackupReconcilingCondition(namespacedBackup, err)
+	}
+
+	clusterBackup := &v1.Backup{
+		ObjectMeta: childObjectMeta(namespacedBackup.Namespace, namespacedBackup.Name),
+		Spec: v1.BackupSpec{
+			StorageLocation:            storageLocation,
+			ResourceSetName:            resourceSet.Name,
+			EncryptionConfigSecretName: namespacedBackup.Spec.EncryptionConfigSecretName,
+			Schedule:                   namespacedBackup.Spec.Schedule,
+			RetentionCount:             namespacedBackup.Spec.RetentionCount,
+			Suspend:                    namespacedBackup.Spec.Suspend,
+			RestrictToNamespace:        namespacedBackup.Namespace,
+			ServiceAccountName:         namespacedBackup.Spec.ServiceAccountName,
+		},
+	}
+	// pass on the annotations to take and cancel a backup, so tenants can use them on the NamespacedBackup
+	for _, annotation := range []string{backup.TriggerAnnotation, backup.CancelAnnotation} {
+		if value, ok := namespacedBackup.Annotations[annotation]; ok {
+			if clusterBackup.Annotations == nil {
+				clusterBackup.Annotations = make(map[string]string)
+			}
+			clusterBackup.Annotations[annotation] = value
+		}
+	}
+	if err := h.applyBackup(clusterBackup, namespacedBackup.Namespace); err != nil {
+		return h.setNamespacedBackupReconcilingCondition(namespacedBackup, err)
+	}
+	return namespacedBackup, nil
+}
+
+func (h *handler) OnNamespacedBackupRemove(_ string, namespacedBackup *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	name := childName(namespacedBackup.Namespace, namespacedBackup.Name)
+	logrus.Infof("Deleting backup CR and resourceSet %v of namespaced backup CR %v/%v", name, namespacedBackup.Namespace, namespacedBackup.Name)
+	if err := h.backups.Delete(name, &k8sv1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return namespacedBackup, err
+	}
+	if err := h.resourceSets.Delete(name, &k8sv1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return namespacedBackup, err
+	}
+	return namespacedBackup, nil
+}
+
+// OnBackupChange copies the status of a Backup created for a NamespacedBackup to it
+func (h *handler) OnBackupChange(_ string, clusterBackup *v1.Backup) (*v1.Backup, error) {
+	if clusterBackup == nil {
+		return clusterBackup, nil
+	}
+	namespace, name, ok := ownerOfChild(clusterBackup)
+	if !ok {
+		return clusterBackup, nil
+	}
+	namespacedBackup, err := h.namespacedBackups.Cache().Get(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return clusterBackup, nil
+	}
+	if err != nil {
+		return clusterBackup, err
+	}
+	if reflect.DeepEqual(namespacedBackup.Status, clusterBackup.Status) {
+		return clusterBackup, nil
+	}
+	namespacedBackup = namespacedBackup.DeepCopy()
+	namespacedBackup.Status = clusterBackup.Status
+	_, err = h.namespacedBackups.UpdateStatus(namespacedBackup)
+	return clusterBackup, err
+}
+
+func (h *handler) applyResourceSet(resourceSet *v1.ResourceSet, namespace string) error {
+	existing, err := h.resourceSets.Get(resourceSet.Name, k8sv1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = h.resourceSets.Create(resourceSet)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkOwnedChild(existing, namespace); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(existing.ResourceSelectors, resourceSet.ResourceSelectors) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.ResourceSelectors = resourceSet.ResourceSelectors
+	_, err = h.resourceSets.Update(existing)
+	return err
+}
+
+func (h *handler) applyBackup(clusterBackup *v1.Backup, namespace string) error {
+	existing, err := h.backups.Get(clusterBackup.Name, k8sv1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logrus.Infof("Creating backup CR %v restricted to namespace %v", clusterBackup.Name, namespace)
+		_, err = h.backups.Create(clusterBackup)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkOwnedChild(existing, namespace); err != nil {
+		return err
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = clusterBackup.Spec
+	for _, annotation := range []string{backup.TriggerAnnotation, backup.CancelAnnotation} {
+		if value, ok := clusterBackup.Annotations[annotation]; ok {
+			if updated.Annotations == nil {
+				updated.Annotations = make(map[string]string)
+			}
+			updated.Annotations[annotation] = value
+		}
+	}
+	if reflect.DeepEqual(existing.Spec, updated.Spec) && reflect.DeepEqual(existing.Annotations, updated.Annotations) {
+		return nil
+	}
+	_, err = h.backups.Update(updated)
+	return err
+}
+
+// suspendBackup suspends the Backup with the given name created for the namespace, if there is one
+func (h *handler) suspendBackup(name, namespace string) error {
+	existing, err := h.backups.Get(name, k8sv1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkOwnedChild(existing, namespace); err != nil || existing.Spec.Suspend {
+		return err
+	}
+	logrus.Infof("Suspending backup CR %v restricted to namespace %v", name, namespace)
+	existing = existing.DeepCopy()
+	existing.Spec.Suspend = true
+	_, err = h.backups.Update(existing)
+	return err
+}
+
+func (h *handler) setNamespacedBackupReconcilingCondition(namespacedBackup *v1.NamespacedBackup, originalErr error) (*v1.NamespacedBackup, error) {
+	if !condition.Cond(v1.BackupConditionReconciling).IsUnknown(namespacedBackup) && condition.Cond(v1.BackupConditionReconciling).GetReason(namespacedBackup) == "Error" {
+		reconcileMsg := condition.Cond(v1.BackupConditionReconciling).GetMessage(namespacedBackup)
+		if strings.Contains(reconcileMsg, originalErr.Error()) {
+			// no need to update object status again, the controller would process the same object immediately without its default backoff
+			return namespacedBackup, originalErr
+		}
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updNamespacedBackup, err := h.namespacedBackups.Get(namespacedBackup.Namespace, namespacedBackup.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		condition.Cond(v1.BackupConditionReconciling).SetStatusBool(updNamespacedBackup, true)
+		condition.Cond(v1.BackupConditionReconciling).SetError(updNamespacedBackup, "", originalErr)
+		condition.Cond(v1.BackupConditionReady).Message(updNamespacedBackup, "Retrying")
+
+		_, err = h.namespacedBackups.UpdateStatus(updNamespacedBackup)
+		return err
+	})
+	if err != nil {
+		return namespacedBackup, errors.New(originalErr.Error() + err.Error())
+	}
+	return namespacedBackup, originalErr
+}
@@ -0,0 +1,132 @@
+package namespaced
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	backupControllers "github.com/rancher/backup-restore-operator/pkg/generated/controllers/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OwnerNamespaceLabel is set on the cluster-scoped Backups, ResourceSets and Restores created for NamespacedBackups and
+// NamespacedRestores, to the namespace of the object they were created for
+const OwnerNamespaceLabel = "resources.cattle.io/owner-namespace"
+
+type handler struct {
+	namespacedBackups  backupControllers.NamespacedBackupController
+	namespacedRestores backupControllers.NamespacedRestoreController
+	backups            backupControllers.BackupController
+	resourceSets       backupControllers.ResourceSetController
+	restores           backupControllers.RestoreController
+}
+
+// Register starts the controllers carrying out NamespacedBackups and NamespacedRestores through cluster-scoped Backups and Restores
+// restricted to their namespace, whose status is copied back to them
+func Register(
+	ctx context.Context,
+	namespacedBackups backupControllers.NamespacedBackupController,
+	namespacedRestores backupControllers.NamespacedRestoreController,
+	backups backupControllers.BackupController,
+	resourceSets backupControllers.ResourceSetController,
+	restores backupControllers.RestoreController) {
+
+	controller := &handler{
+		namespacedBackups:  namespacedBackups,
+		namespacedRestores: namespacedRestores,
+		backups:            backups,
+		resourceSets:       resourceSets,
+		restores:           restores,
+	}
+
+	// Register handlers
+	namespacedBackups.OnChange(ctx, "namespaced-backups", controller.OnNamespacedBackupChange)
+	namespacedBackups.OnRemove(ctx, "namespaced-backups", controller.OnNamespacedBackupRemove)
+	backups.OnChange(ctx, "namespaced-backups-status", controller.OnBackupChange)
+	namespacedRestores.OnChange(ctx, "namespaced-restores", controller.OnNamespacedRestoreChange)
+	namespacedRestores.OnRemove(ctx, "namespaced-restores", controller.OnNamespacedRestoreRemove)
+	restores.OnChange(ctx, "namespaced-restores-status", controller.OnRestoreChange)
+}
+
+// childName is the name of the cluster-scoped object created for a namespaced one. Namespace names can't contain dots,
+// so names of objects created for different namespaces can't collide
+func childName(namespace, name string) string {
+	return namespace + "." + name
+}
+
+func childObjectMeta(namespace, name string) k8sv1.ObjectMeta {
+	return k8sv1.ObjectMeta{
+		Name:   childName(namespace, name),
+		Labels: map[string]string{OwnerNamespaceLabel: namespace},
+	}
+}
+
+// ownerOfChild returns the namespace and name of the namespaced object the given cluster-scoped object was created for
+func ownerOfChild(child k8sv1.Object) (string, string, bool) {
+	namespace := child.GetLabels()[OwnerNamespaceLabel]
+	if namespace == "" || !strings.HasPrefix(child.GetName(), namespace+".") {
+		return "", "", false
+	}
+	return namespace, strings.TrimPrefix(child.GetName(), namespace+"."), true
+}
+
+// checkOwnedChild makes sure an existing cluster-scoped object was created for the given namespace, so a namespaced object
+// can't take over a Backup or Restore created by a cluster admin under the same name
+func checkOwnedChild(child k8sv1.Object, namespace string) error {
+	if child.GetLabels()[OwnerNamespaceLabel] != namespace {
+		return fmt.Errorf("%v already exists and was not created for namespace %v", child.GetName(), namespace)
+	}
+	return nil
+}
+
+// checkServiceAccountName makes sure a namespaced object names the ServiceAccount of its namespace to run as. Without one its
+// Backup or Restore would run with the operator's own permissions, letting tenants read and write what their RBAC doesn't allow
+func checkServiceAccountName(serviceAccountName string) error {
+	if serviceAccountName == "" {
+		return fmt.Errorf("serviceAccountName must be set, namespaced backups and restores never run with the operator's permissions")
+	}
+	return nil
+}
+
+// confinedStorageLocation returns a copy of the S3 storage location reading its credential secret from the given namespace
+func confinedStorageLocation(location *v1.StorageLocation, namespace string) (*v1.StorageLocation, error) {
+	if location == nil || location.S3 == nil {
+		return nil, fmt.Errorf("storageLocation.s3 must be set")
+	}
+	if location.S3.CredentialSecretNamespace != "" && location.S3.CredentialSecretNamespace != namespace {
+		return nil, fmt.Errorf("credentialSecretNamespace must be empty or %v", namespace)
+	}
+	location = location.DeepCopy()
+	location.S3.CredentialSecretNamespace = namespace
+	return location, nil
+}
+
+// confinedResourceSelectors returns copies of the resource selectors restricted to the given namespace.
+// Selectors naming other namespaces are rejected rather than silently restricted
+func confinedResourceSelectors(resourceSelectors []v1.ResourceSelector, namespace string) ([]v1.ResourceSelector, error) {
+	var confined []v1.ResourceSelector
+	for _, resourceSelector := range resourceSelectors {
+		for _, ns := range resourceSelector.Namespaces {
+			if ns != namespace {
+				return nil, fmt.Errorf("resource selector for %v can't select namespace %v", resourceSelector.APIVersion, ns)
+			}
+		}
+		if resourceSelector.NamespaceRegexp != "" {
+			matched, err := regexp.MatchString(resourceSelector.NamespaceRegexp, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				return nil, fmt.Errorf("namespaceRegexp %v of resource selector for %v doesn't match namespace %v",
+					resourceSelector.NamespaceRegexp, resourceSelector.APIVersion, namespace)
+			}
+		}
+		restricted := resourceSelector.DeepCopy()
+		restricted.Namespaces = []string{namespace}
+		restricted.NamespaceRegexp = ""
+		confined = append(confined, *restricted)
+	}
+	return confined, nil
+}
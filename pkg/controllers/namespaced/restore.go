@@ -0,0 +1,128 @@
+package namespaced
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+func (h *handler) OnNamespacedRestoreChange(_ string, namespacedRestore *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	if namespacedRestore == nil || namespacedRestore.DeletionTimestamp != nil {
+		return namespacedRestore, nil
+	}
+	if err := checkServiceAccountName(namespacedRestore.Spec.ServiceAccountName); err != nil {
+		return h.setNamespacedRestoreReconcilingCondition(namespacedRestore, err)
+	}
+	storageLocation, err := confinedStorageLocation(namespacedRestore.Spec.StorageLocation, namespacedRestore.Namespace)
+	if err != nil {
+		return h.setNamespacedRestoreReconcilingCondition(namespacedRestore, err)
+	}
+
+	clusterRestore := &v1.Restore{
+		ObjectMeta: childObjectMeta(namespacedRestore.Namespace, namespacedRestore.Name),
+		Spec: v1.RestoreSpec{
+			BackupFilename:             namespacedRestore.Spec.BackupFilename,
+			StorageLocation:            storageLocation,
+			Prune:                      namespacedRestore.Spec.Prune,
+			DeleteTimeoutSeconds:       namespacedRestore.Spec.DeleteTimeoutSeconds,
+			EncryptionConfigSecretName: namespacedRestore.Spec.EncryptionConfigSecretName,
+			RestrictToNamespace:        namespacedRestore.Namespace,
+			ServiceAccountName:         namespacedRestore.Spec.ServiceAccountName,
+			BackupTagSelector:          namespacedRestore.Spec.BackupTagSelector,
+			DryRun:                     namespacedRestore.Spec.DryRun,
+		},
+	}
+	if err := h.applyRestore(clusterRestore, namespacedRestore.Namespace); err != nil {
+		return h.setNamespacedRestoreReconcilingCondition(namespacedRestore, err)
+	}
+	return namespacedRestore, nil
+}
+
+func (h *handler) OnNamespacedRestoreRemove(_ string, namespacedRestore *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	name := childName(namespacedRestore.Namespace, namespacedRestore.Name)
+	logrus.Infof("Deleting restore CR %v of namespaced restore CR %v/%v", name, namespacedRestore.Namespace, namespacedRestore.Name)
+	if err := h.restores.Delete(name, &k8sv1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return namespacedRestore, err
+	}
+	return namespacedRestore, nil
+}
+
+// OnRestoreChange copies the status of a Restore created for a NamespacedRestore to it
+func (h *handler) OnRestoreChange(_ string, clusterRestore *v1.Restore) (*v1.Restore, error) {
+	if clusterRestore == nil {
+		return clusterRestore, nil
+	}
+	namespace, name, ok := ownerOfChild(clusterRestore)
+	if !ok {
+		return clusterRestore, nil
+	}
+	namespacedRestore, err := h.namespacedRestores.Cache().Get(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return clusterRestore, nil
+	}
+	if err != nil {
+		return clusterRestore, err
+	}
+	if reflect.DeepEqual(namespacedRestore.Status, clusterRestore.Status) {
+		return clusterRestore, nil
+	}
+	namespacedRestore = namespacedRestore.DeepCopy()
+	namespacedRestore.Status = clusterRestore.Status
+	_, err = h.namespacedRestores.UpdateStatus(namespacedRestore)
+	return clusterRestore, err
+}
+
+func (h *handler) applyRestore(clusterRestore *v1.Restore, namespace string) error {
+	existing, err := h.restores.Get(clusterRestore.Name, k8sv1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logrus.Infof("Creating restore CR %v restricted to namespace %v", clusterRestore.Name, namespace)
+		_, err = h.restores.Create(clusterRestore)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkOwnedChild(existing, namespace); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(existing.Spec, clusterRestore.Spec) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Spec = clusterRestore.Spec
+	_, err = h.restores.Update(existing)
+	return err
+}
+
+func (h *handler) setNamespacedRestoreReconcilingCondition(namespacedRestore *v1.NamespacedRestore, originalErr error) (*v1.NamespacedRestore, error) {
+	if !condition.Cond(v1.RestoreConditionReconciling).IsUnknown(namespacedRestore) && condition.Cond(v1.RestoreConditionReconciling).GetReason(namespacedRestore) == "Error" {
+		reconcileMsg := condition.Cond(v1.RestoreConditionReconciling).GetMessage(namespacedRestore)
+		if strings.Contains(reconcileMsg, originalErr.Error()) {
+			// no need to update object status again, the controller would process the same object immediately without its default backoff
+			return namespacedRestore, originalErr
+		}
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updNamespacedRestore, err := h.namespacedRestores.Get(namespacedRestore.Namespace, namespacedRestore.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		condition.Cond(v1.RestoreConditionReconciling).SetStatusBool(updNamespacedRestore, true)
+		condition.Cond(v1.RestoreConditionReconciling).SetError(updNamespacedRestore, "", originalErr)
+		condition.Cond(v1.RestoreConditionReady).Message(updNamespacedRestore, "Retrying")
+
+		_, err = h.namespacedRestores.UpdateStatus(updNamespacedRestore)
+		return err
+	})
+	if err != nil {
+		return namespacedRestore, errors.New(originalErr.Error() + err.Error())
+	}
+	return namespacedRestore, originalErr
+}
@@ -0,0 +1,70 @@
+package restore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// fieldManager owns the fields of the objects restored with server-side apply
+const fieldManager = "backup-restore-operator"
+
+func validateApplyMode(restore *v1.Restore) error {
+	switch restore.Spec.ApplyMode {
+	case "", v1.ApplyModeUpdate:
+		if restore.Spec.ForceConflicts {
+			return fmt.Errorf("forceConflicts can only be set with applyMode %v", v1.ApplyModeServerSideApply)
+		}
+		return nil
+	case v1.ApplyModeServerSideApply:
+		return nil
+	}
+	return fmt.Errorf("invalid applyMode %v, must be %v or %v", restore.Spec.ApplyMode, v1.ApplyModeUpdate, v1.ApplyModeServerSideApply)
+}
+
+// serverSideApplying returns a copy of the handler that restores objects with server-side apply instead of creating or updating them
+func (h *handler) serverSideApplying(forceConflicts bool) *handler {
+	applying := *h
+	applying.applyOptions = &k8sv1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &forceConflicts,
+	}
+	return &applying
+}
+
+// applyResource restores the object with server-side apply, creating it if it doesn't exist, then applies its status to the status
+// subresource if it has one. The fields of the live object that the backup doesn't set are kept, and fields owned by other managers
+// fail the apply with a conflict unless forceConflicts is set
+func (h *handler) applyResource(dr dynamic.ResourceInterface, obj unstructured.Unstructured, hasStatusSubresource bool) error {
+	// the apiserver rejects applied objects with managedFields, and a resourceVersion would have to match the live object's
+	unstructured.RemoveNestedField(obj.Object, metadataMapKey, "managedFields")
+	unstructured.RemoveNestedField(obj.Object, metadataMapKey, "resourceVersion")
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return err
+	}
+	if _, err := dr.Patch(h.ctx, obj.GetName(), types.ApplyPatchType, data, *h.applyOptions); err != nil {
+		return fmt.Errorf("restoreResource: err applying resource %w", err)
+	}
+	status, ok := obj.Object["status"]
+	if !hasStatusSubresource || !ok {
+		return nil
+	}
+	statusObj := unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+	statusObj.SetAPIVersion(obj.GetAPIVersion())
+	statusObj.SetKind(obj.GetKind())
+	statusObj.SetName(obj.GetName())
+	statusObj.SetNamespace(obj.GetNamespace())
+	if data, err = json.Marshal(statusObj.Object); err != nil {
+		return err
+	}
+	if _, err := dr.Patch(h.ctx, obj.GetName(), types.ApplyPatchType, data, *h.applyOptions, "status"); err != nil {
+		return fmt.Errorf("restoreResource: err applying status resource %w", err)
+	}
+	return nil
+}
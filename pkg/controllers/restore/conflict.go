@@ -0,0 +1,141 @@
+package restore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Decisions taken for the objects of the backup file that already exist in the cluster
+const (
+	conflictDecisionOverwritten = "Overwritten"
+	conflictDecisionSkipped     = "Skipped"
+	conflictDecisionFailed      = "Failed"
+)
+
+// conflict is the decision taken for an object of the backup file that already exists
+type conflict struct {
+	Policy   string `json:"policy"`
+	Decision string `json:"decision"`
+	// ModifiedAt is the last time the live object was modified, compared with the backup time by SkipIfModifiedAfterBackup
+	ModifiedAt string `json:"modifiedAt,omitempty"`
+}
+
+// conflictPolicies are the Restore's conflictPolicy and its overrides per resource
+type conflictPolicies struct {
+	defaultPolicy string
+	resources     map[schema.GroupVersionResource]string
+	// backupTime is set once the backup file is loaded
+	backupTime time.Time
+}
+
+func newConflictPolicies(restore *v1.Restore) (*conflictPolicies, error) {
+	policies := &conflictPolicies{
+		defaultPolicy: v1.ConflictPolicyOverwrite,
+		resources:     make(map[schema.GroupVersionResource]string),
+	}
+	if restore.Spec.ConflictPolicy != "" {
+		if err := validateConflictPolicy(restore.Spec.ConflictPolicy); err != nil {
+			return nil, fmt.Errorf("invalid conflictPolicy: %v", err)
+		}
+		policies.defaultPolicy = restore.Spec.ConflictPolicy
+	}
+	for _, resourcePolicy := range restore.Spec.ResourceConflictPolicies {
+		if resourcePolicy.APIVersion == "" || resourcePolicy.Resource == "" {
+			return nil, fmt.Errorf("resourceConflictPolicies must have an apiVersion and a resource")
+		}
+		gv, err := schema.ParseGroupVersion(resourcePolicy.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid apiVersion %v in resourceConflictPolicies: %v", resourcePolicy.APIVersion, err)
+		}
+		gvr := gv.WithResource(resourcePolicy.Resource)
+		if err := validateConflictPolicy(resourcePolicy.Policy); err != nil {
+			return nil, fmt.Errorf("invalid conflict policy for %v: %v", gvr, err)
+		}
+		if _, ok := policies.resources[gvr]; ok {
+			return nil, fmt.Errorf("resourceConflictPolicies has more than one policy for %v", gvr)
+		}
+		policies.resources[gvr] = resourcePolicy.Policy
+	}
+	return policies, nil
+}
+
+func validateConflictPolicy(policy string) error {
+	switch policy {
+	case v1.ConflictPolicyOverwrite, v1.ConflictPolicySkipIfExists, v1.ConflictPolicyFailIfExists, v1.ConflictPolicySkipIfModifiedAfterBackup:
+		return nil
+	}
+	return fmt.Errorf("unknown policy %v, must be %v, %v, %v or %v", policy, v1.ConflictPolicyOverwrite, v1.ConflictPolicySkipIfExists,
+		v1.ConflictPolicyFailIfExists, v1.ConflictPolicySkipIfModifiedAfterBackup)
+}
+
+// resolvingConflicts returns a copy of the handler that decides what to do with the objects that already exist following the policies
+func (h *handler) resolvingConflicts(policies *conflictPolicies) *handler {
+	resolving := *h
+	resolving.conflicts = policies
+	return &resolving
+}
+
+// resolve decides what to do with the object of the given resource from the backup file, which exists as the live object
+func (p *conflictPolicies) resolve(gvr schema.GroupVersionResource, live *unstructured.Unstructured) conflict {
+	policy, ok := p.resources[gvr]
+	if !ok {
+		policy = p.defaultPolicy
+	}
+	objConflict := conflict{
+		Policy:   policy,
+		Decision: conflictDecisionOverwritten,
+	}
+	switch policy {
+	case v1.ConflictPolicySkipIfExists:
+		objConflict.Decision = conflictDecisionSkipped
+	case v1.ConflictPolicyFailIfExists:
+		objConflict.Decision = conflictDecisionFailed
+	case v1.ConflictPolicySkipIfModifiedAfterBackup:
+		modifiedAt := lastModified(live)
+		objConflict.ModifiedAt = modifiedAt.Format(time.RFC3339)
+		if modifiedAt.After(p.backupTime) {
+			objConflict.Decision = conflictDecisionSkipped
+		}
+	}
+	return objConflict
+}
+
+// lastModified returns the last time the object was changed, as recorded by its managed fields. Changes to its status alone are
+// ignored, since controllers keep updating it. Objects without managed fields fall back to their creation time
+func lastModified(obj *unstructured.Unstructured) time.Time {
+	var modifiedAt time.Time
+	for _, managedFields := range obj.GetManagedFields() {
+		if managedFields.Time == nil || managesStatusOnly(managedFields) {
+			continue
+		}
+		if managedFields.Time.After(modifiedAt) {
+			modifiedAt = managedFields.Time.Time
+		}
+	}
+	if modifiedAt.IsZero() {
+		return obj.GetCreationTimestamp().Time
+	}
+	return modifiedAt
+}
+
+func managesStatusOnly(managedFields k8sv1.ManagedFieldsEntry) bool {
+	if managedFields.FieldsV1 == nil {
+		return false
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(managedFields.FieldsV1.Raw, &fields); err != nil || len(fields) == 0 {
+		return false
+	}
+	for field := range fields {
+		if field != "f:status" {
+			return false
+		}
+	}
+	return true
+}
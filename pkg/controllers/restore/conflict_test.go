@@ -0,0 +1,204 @@
+package restore
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var backupTime = time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+func managedFieldsEntry(manager string, at time.Time, fields string) k8sv1.ManagedFieldsEntry {
+	entry := k8sv1.ManagedFieldsEntry{Manager: manager, Operation: k8sv1.ManagedFieldsOperationUpdate}
+	if !at.IsZero() {
+		entry.Time = &k8sv1.Time{Time: at}
+	}
+	if fields != "" {
+		entry.FieldsType = "FieldsV1"
+		entry.FieldsV1 = &k8sv1.FieldsV1{Raw: []byte(fields)}
+	}
+	return entry
+}
+
+func liveObject(created time.Time, managedFields ...k8sv1.ManagedFieldsEntry) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "ConfigMap"}}
+	obj.SetName("config")
+	obj.SetCreationTimestamp(k8sv1.Time{Time: created})
+	obj.SetManagedFields(managedFields)
+	return obj
+}
+
+func TestNewConflictPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    v1.RestoreSpec
+		wantErr bool
+	}{
+		{
+			name: "default policy",
+		},
+		{
+			name: "policy with overrides",
+			spec: v1.RestoreSpec{
+				ConflictPolicy: v1.ConflictPolicySkipIfExists,
+				ResourceConflictPolicies: []v1.ResourceConflictPolicy{
+					{APIVersion: "v1", Resource: "configmaps", Policy: v1.ConflictPolicyOverwrite},
+					{APIVersion: "apps/v1", Resource: "deployments", Policy: v1.ConflictPolicySkipIfModifiedAfterBackup},
+				},
+			},
+		},
+		{
+			name:    "unknown policy",
+			spec:    v1.RestoreSpec{ConflictPolicy: "Merge"},
+			wantErr: true,
+		},
+		{
+			name: "unknown resource policy",
+			spec: v1.RestoreSpec{ResourceConflictPolicies: []v1.ResourceConflictPolicy{
+				{APIVersion: "v1", Resource: "configmaps", Policy: "Merge"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "resource policy without a resource",
+			spec: v1.RestoreSpec{ResourceConflictPolicies: []v1.ResourceConflictPolicy{
+				{APIVersion: "v1", Policy: v1.ConflictPolicyOverwrite},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid apiVersion",
+			spec: v1.RestoreSpec{ResourceConflictPolicies: []v1.ResourceConflictPolicy{
+				{APIVersion: "apps/v1/beta", Resource: "deployments", Policy: v1.ConflictPolicyOverwrite},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "two policies for one resource",
+			spec: v1.RestoreSpec{ResourceConflictPolicies: []v1.ResourceConflictPolicy{
+				{APIVersion: "v1", Resource: "configmaps", Policy: v1.ConflictPolicyOverwrite},
+				{APIVersion: "v1", Resource: "configmaps", Policy: v1.ConflictPolicySkipIfExists},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newConflictPolicies(&v1.Restore{Spec: tt.spec}); (err != nil) != tt.wantErr {
+				t.Errorf("newConflictPolicies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	before := backupTime.Add(-time.Hour)
+	after := backupTime.Add(time.Hour)
+	tests := []struct {
+		name           string
+		policy         string
+		configMaps     string
+		live           *unstructured.Unstructured
+		wantPolicy     string
+		wantDecision   string
+		wantModifiedAt time.Time
+	}{
+		{
+			name:         "overwrite by default",
+			live:         liveObject(after),
+			wantPolicy:   v1.ConflictPolicyOverwrite,
+			wantDecision: conflictDecisionOverwritten,
+		},
+		{
+			name:         "skip if exists",
+			policy:       v1.ConflictPolicySkipIfExists,
+			live:         liveObject(before),
+			wantPolicy:   v1.ConflictPolicySkipIfExists,
+			wantDecision: conflictDecisionSkipped,
+		},
+		{
+			name:         "fail if exists",
+			policy:       v1.ConflictPolicyFailIfExists,
+			live:         liveObject(before),
+			wantPolicy:   v1.ConflictPolicyFailIfExists,
+			wantDecision: conflictDecisionFailed,
+		},
+		{
+			name:         "the policy of the resource overrides the default",
+			policy:       v1.ConflictPolicyFailIfExists,
+			configMaps:   v1.ConflictPolicySkipIfExists,
+			live:         liveObject(before),
+			wantPolicy:   v1.ConflictPolicySkipIfExists,
+			wantDecision: conflictDecisionSkipped,
+		},
+		{
+			name:           "overwrite if not modified after the backup",
+			policy:         v1.ConflictPolicySkipIfModifiedAfterBackup,
+			live:           liveObject(before.Add(-time.Hour), managedFieldsEntry("kubectl", before, `{"f:data":{}}`)),
+			wantPolicy:     v1.ConflictPolicySkipIfModifiedAfterBackup,
+			wantDecision:   conflictDecisionOverwritten,
+			wantModifiedAt: before,
+		},
+		{
+			name:           "skip if modified after the backup",
+			policy:         v1.ConflictPolicySkipIfModifiedAfterBackup,
+			live:           liveObject(before, managedFieldsEntry("kubectl", after, `{"f:data":{}}`)),
+			wantPolicy:     v1.ConflictPolicySkipIfModifiedAfterBackup,
+			wantDecision:   conflictDecisionSkipped,
+			wantModifiedAt: after,
+		},
+		{
+			name:   "status updates after the backup are no modification",
+			policy: v1.ConflictPolicySkipIfModifiedAfterBackup,
+			live: liveObject(before.Add(-time.Hour),
+				managedFieldsEntry("kubectl", before, `{"f:data":{}}`),
+				managedFieldsEntry("controller", after, `{"f:status":{}}`)),
+			wantPolicy:     v1.ConflictPolicySkipIfModifiedAfterBackup,
+			wantDecision:   conflictDecisionOverwritten,
+			wantModifiedAt: before,
+		},
+		{
+			name:           "objects without managed fields were last modified when created",
+			policy:         v1.ConflictPolicySkipIfModifiedAfterBackup,
+			live:           liveObject(after),
+			wantPolicy:     v1.ConflictPolicySkipIfModifiedAfterBackup,
+			wantDecision:   conflictDecisionSkipped,
+			wantModifiedAt: after,
+		},
+		{
+			name:           "managed fields without a time are ignored",
+			policy:         v1.ConflictPolicySkipIfModifiedAfterBackup,
+			live:           liveObject(before, managedFieldsEntry("kubectl", time.Time{}, `{"f:data":{}}`)),
+			wantPolicy:     v1.ConflictPolicySkipIfModifiedAfterBackup,
+			wantDecision:   conflictDecisionOverwritten,
+			wantModifiedAt: before,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := v1.RestoreSpec{ConflictPolicy: tt.policy}
+			if tt.configMaps != "" {
+				spec.ResourceConflictPolicies = []v1.ResourceConflictPolicy{{APIVersion: "v1", Resource: "configmaps", Policy: tt.configMaps}}
+			}
+			policies, err := newConflictPolicies(&v1.Restore{Spec: spec})
+			if err != nil {
+				t.Fatalf("newConflictPolicies() error = %v", err)
+			}
+			policies.backupTime = backupTime
+			got := policies.resolve(configMapsGVR, tt.live)
+			if got.Policy != tt.wantPolicy || got.Decision != tt.wantDecision {
+				t.Errorf("resolve() = %v %v, want %v %v", got.Policy, got.Decision, tt.wantPolicy, tt.wantDecision)
+			}
+			wantModifiedAt := ""
+			if !tt.wantModifiedAt.IsZero() {
+				wantModifiedAt = tt.wantModifiedAt.Format(time.RFC3339)
+			}
+			if got.ModifiedAt != wantModifiedAt {
+				t.Errorf("resolve() modifiedAt = %v, want %v", got.ModifiedAt, wantModifiedAt)
+			}
+		})
+	}
+}
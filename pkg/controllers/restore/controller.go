@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	restoreControllers "github.com/rancher/backup-restore-operator/pkg/generated/controllers/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
+	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
 	"github.com/rancher/backup-restore-operator/pkg/util"
 	lasso "github.com/rancher/lasso/pkg/client"
 	v1core "github.com/rancher/wrangler-api/pkg/generated/controllers/core/v1"
@@ -19,6 +22,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +35,8 @@ import (
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	coordinationclientv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
 )
@@ -41,6 +47,20 @@ const (
 	clusterScoped   = "clusterscoped"
 	namespaceScoped = "namespaceScoped"
 	leaseName       = "restore-controller"
+	// maxConcurrency limits the load a Restore can put on the apiserver
+	maxConcurrency = 100
+)
+
+// Reasons for the Events recorded on Restore CRs
+const (
+	eventReasonStarted        = "RestoreStarted"
+	eventReasonCompleted      = "RestoreCompleted"
+	eventReasonFailed         = "RestoreFailed"
+	eventReasonCRDWaitTimeout = "CRDWaitTimeout"
+	eventReasonPruned         = "Pruned"
+	eventReasonScaledDown     = "ControllerScaledDown"
+	eventReasonScaledUp       = "ControllerScaledUp"
+	eventReasonDryRun         = "DryRunCompleted"
 )
 
 type handler struct {
@@ -48,14 +68,36 @@ type handler struct {
 	restores                restoreControllers.RestoreController
 	backups                 restoreControllers.BackupController
 	secrets                 v1core.SecretController
+	configMaps              v1core.ConfigMapController
 	discoveryClient         discovery.DiscoveryInterface
 	apiClient               clientset.Interface
 	dynamicClient           dynamic.Interface
+	storageDynamicClient    dynamic.Interface
+	restConfig              *rest.Config
 	sharedClientFactory     lasso.SharedClientFactory
 	restmapper              meta.RESTMapper
 	defaultBackupMountPath  string
 	defaultS3BackupLocation *v1.S3ObjectStore
+	// defaultResourcePriority orders the resources of Restores that don't set resourcePriority
+	defaultResourcePriority []schema.GroupResource
 	kubernetesLeaseClient   coordinationclientv1.LeaseInterface
+	recorder                record.EventRecorder
+	// plan is set while planning a dry-run Restore, objects are then compared with the live objects instead of being restored
+	plan *restorePlan
+	// transformations are the compiled transformation rules of the Restore, applied to every object before it is restored
+	transformations []transformation
+	// conflicts decide what to do with the objects of the backup file that already exist
+	conflicts *conflictPolicies
+	// applyOptions are set to restore objects with server-side apply
+	applyOptions *k8sv1.PatchOptions
+	// recreateKinds are the kinds whose objects may be deleted and created again when updating them fails on immutable fields
+	recreateKinds map[schema.GroupVersionKind]bool
+	// recreateTimeout is how long to wait for an object being recreated to be deleted
+	recreateTimeout time.Duration
+	// concurrency is the number of objects restored at once, util.WorkerThreads if unset
+	concurrency int
+	// report is set while restoring a Restore that isn't a dry run, and saved in a ConfigMap once the objects are restored or failed to
+	report *restoreReport
 }
 
 type ObjectsFromBackupCR struct {
@@ -65,6 +107,22 @@ type ObjectsFromBackupCR struct {
 	resourcesFromBackup             map[string]bool
 	resourcesWithStatusSubresource  map[string]bool
 	backupResourceSet               v1.ResourceSet
+	// excludedResources are the paths of the objects in the backup file that the Restore's resource selectors don't restore
+	excludedResources map[string]bool
+	// backupTime is the earliest modification time of the objects' files in the backup file
+	backupTime time.Time
+}
+
+func newObjectsFromBackupCR() ObjectsFromBackupCR {
+	return ObjectsFromBackupCR{
+		crdInfoToData:                   make(map[objInfo]unstructured.Unstructured),
+		clusterscopedResourceInfoToData: make(map[objInfo]unstructured.Unstructured),
+		namespacedResourceInfoToData:    make(map[objInfo]unstructured.Unstructured),
+		resourcesFromBackup:             make(map[string]bool),
+		resourcesWithStatusSubresource:  make(map[string]bool),
+		backupResourceSet:               v1.ResourceSet{},
+		excludedResources:               make(map[string]bool),
+	}
 }
 
 type objInfo struct {
@@ -87,34 +145,38 @@ func Register(
 	restores restoreControllers.RestoreController,
 	backups restoreControllers.BackupController,
 	secrets v1core.SecretController,
+	configMaps v1core.ConfigMapController,
 	leaseClient coordinationclientv1.LeaseInterface,
 	clientSet *clientset.Clientset,
 	dynamicInterface dynamic.Interface,
+	restConfig *rest.Config,
 	sharedClientFactory lasso.SharedClientFactory,
 	restmapper meta.RESTMapper,
+	recorder record.EventRecorder,
 	defaultLocalBackupLocation string,
-	defaultS3 *v1.S3ObjectStore) {
+	defaultS3 *v1.S3ObjectStore,
+	defaultResourcePriority []schema.GroupResource) {
 
 	controller := &handler{
 		ctx:                     ctx,
+		recorder:                recorder,
 		restores:                restores,
 		backups:                 backups,
 		secrets:                 secrets,
+		configMaps:              configMaps,
 		dynamicClient:           dynamicInterface,
+		storageDynamicClient:    dynamicInterface,
+		restConfig:              restConfig,
 		discoveryClient:         clientSet.Discovery(),
 		apiClient:               clientSet,
 		sharedClientFactory:     sharedClientFactory,
 		restmapper:              restmapper,
 		defaultBackupMountPath:  defaultLocalBackupLocation,
 		defaultS3BackupLocation: defaultS3,
+		defaultResourcePriority: defaultResourcePriority,
 		kubernetesLeaseClient:   leaseClient,
 	}
 
-	lease, err := leaseClient.Get(ctx, leaseName, k8sv1.GetOptions{})
-	if err == nil && lease != nil {
-		leaseClient.Delete(ctx, leaseName, k8sv1.DeleteOptions{})
-	}
-
 	// Register handlers
 	restores.OnChange(ctx, "restore", controller.OnRestoreChange)
 }
@@ -126,35 +188,116 @@ func (h *handler) OnRestoreChange(_ string, restore *v1.Restore) (*v1.Restore, e
 	if restore.Status.RestoreCompletionTS != "" {
 		return restore, nil
 	}
+	restoring := h
+	if restore.Spec.ServiceAccountName != "" {
+		impersonating, err := h.impersonating(restore)
+		if err != nil {
+			return h.setReconcilingCondition(restore, err)
+		}
+		restoring = impersonating
+	}
+	if len(restore.Spec.Transformations) > 0 {
+		transforming, err := restoring.transforming(restore)
+		if err != nil {
+			return h.setReconcilingCondition(restore, err)
+		}
+		restoring = transforming
+	}
+	conflicts, err := newConflictPolicies(restore)
+	if err != nil {
+		return h.setReconcilingCondition(restore, err)
+	}
+	restoring = restoring.resolvingConflicts(conflicts)
+	if restore.Spec.ApplyMode == v1.ApplyModeServerSideApply {
+		restoring = restoring.serverSideApplying(restore.Spec.ForceConflicts)
+	}
+	if len(restore.Spec.RecreateKinds) > 0 {
+		recreating, err := restoring.recreating(restore)
+		if err != nil {
+			return h.setReconcilingCondition(restore, err)
+		}
+		restoring = recreating
+	}
+	if restore.Spec.Concurrency > 0 {
+		restoring = restoring.concurrently(restore.Spec.Concurrency)
+	}
+	if restore.Spec.DryRun {
+		restoring = restoring.planning()
+	} else {
+		restoring = restoring.reporting()
+	}
+	return restoring.restore(restore)
+}
+
+func (h *handler) restore(restore *v1.Restore) (*v1.Restore, error) {
 
 	if err := h.Lock(restore); err != nil {
 		return restore, err
 	}
 	defer h.Unlock(*leaseHolderName(restore))
 
+	if restore.Spec.BackupFilename == "" && len(restore.Spec.BackupTagSelector) == 0 {
+		return h.setReconcilingCondition(restore, fmt.Errorf("either backupFilename or backupTagSelector must be set"))
+	}
+	if restore.Spec.BackupFilename != "" && len(restore.Spec.BackupTagSelector) > 0 {
+		return h.setReconcilingCondition(restore, fmt.Errorf("backupFilename and backupTagSelector cannot both be set"))
+	}
+
+	if err := validateNamespaceMapping(restore.Spec.NamespaceMapping); err != nil {
+		return h.setReconcilingCondition(restore, err)
+	}
+	if err := validateApplyMode(restore); err != nil {
+		return h.setReconcilingCondition(restore, err)
+	}
+	if restore.Spec.Concurrency < 0 || restore.Spec.Concurrency > maxConcurrency {
+		return h.setReconcilingCondition(restore, fmt.Errorf("concurrency must be between 0 and %v (0 uses the default)", maxConcurrency))
+	}
+	resourcePriority := h.defaultResourcePriority
+	if len(restore.Spec.ResourcePriority) > 0 {
+		var err error
+		if resourcePriority, err = ParseResourcePriority(restore.Spec.ResourcePriority); err != nil {
+			return h.setReconcilingCondition(restore, err)
+		}
+	}
+
+	if len(restore.Spec.BackupTagSelector) > 0 && restore.Status.BackupFilename == "" {
+		filename, err := h.selectBackupFile(restore)
+		if err != nil {
+			return h.setReconcilingCondition(restore, err)
+		}
+		if restore, err = h.recordBackupFilename(restore, filename); err != nil {
+			return h.setReconcilingCondition(restore, err)
+		}
+	}
+
 	logrus.Infof("Processing Restore CR %v", restore.Name)
+	restoreStartTime := time.Now()
+	metrics.RestoreStarted(restore.Name)
+	// a started run that returns before completing counts once as a failed restore
+	restoreName := restore.Name
+	completed := false
+	defer func() {
+		if !completed {
+			metrics.RestoreFailed(restoreName)
+		}
+	}()
+	h.recorder.Eventf(restore, corev1.EventTypeNormal, eventReasonStarted, "Started restore from %v", backupToRestore(restore))
 	var backupSource string
-	backupName := restore.Spec.BackupFilename
-	logrus.Infof("Restoring from backup %v", restore.Spec.BackupFilename)
+	logrus.Infof("Restoring from backup %v", backupToRestore(restore))
 
 	created := make(map[string]bool)
+	// the errors of the objects that failed to restore or were skipped with their owners, carried over to the later phases and waves
+	notRestored := make(map[string]error)
 	ownerToDependentsList := make(map[string][]restoreObj)
 	var toRestore []restoreObj
 	numOwnerReferences := make(map[string]int)
-	objFromBackupCR := ObjectsFromBackupCR{
-		crdInfoToData:                   make(map[objInfo]unstructured.Unstructured),
-		clusterscopedResourceInfoToData: make(map[objInfo]unstructured.Unstructured),
-		namespacedResourceInfoToData:    make(map[objInfo]unstructured.Unstructured),
-		resourcesFromBackup:             make(map[string]bool),
-		resourcesWithStatusSubresource:  make(map[string]bool),
-		backupResourceSet:               v1.ResourceSet{},
-	}
+	objFromBackupCR := newObjectsFromBackupCR()
 
 	transformerMap := make(map[schema.GroupResource]value.Transformer)
 	var err error
 	if restore.Spec.EncryptionConfigSecretName != "" {
 		logrus.Infof("Processing encryption config %v for restore CR %v", restore.Spec.EncryptionConfigSecretName, restore.Name)
-		transformerMap, err = util.GetEncryptionTransformers(restore.Spec.EncryptionConfigSecretName, h.secrets)
+		transformerMap, err = util.GetEncryptionTransformers(restore.Spec.EncryptionConfigSecretName, restore.Spec.RestrictToNamespace, h.secrets)
 		if err != nil {
 			logrus.Errorf("Error processing encryption config: %v", err)
 			return h.setReconcilingCondition(restore, err)
@@ -180,7 +323,10 @@ func (h *handler) OnRestoreChange(_ string, restore *v1.Restore) (*v1.Restore, e
 			foundBackup = true
 			backupSource = util.S3Backup
 		} else if h.defaultBackupMountPath != "" {
-			backupFilePath := filepath.Join(h.defaultBackupMountPath, backupName)
+			backupFilePath, err := backupFilePathInMountPath(restore, h.defaultBackupMountPath)
+			if err != nil {
+				return h.setReconcilingCondition(restore, err)
+			}
 			if err = h.LoadFromTarGzip(backupFilePath, transformerMap, &objFromBackupCR); err != nil {
 				return h.setReconcilingCondition(restore, err)
 			}
@@ -206,48 +352,78 @@ func (h *handler) OnRestoreChange(_ string, restore *v1.Restore) (*v1.Restore, e
 	if !foundBackup {
 		return h.setReconcilingCondition(restore, fmt.Errorf("Backup location not specified on the restore CR, and not configured at the operator level"))
 	}
+	h.conflicts.backupTime = objFromBackupCR.backupTime
+	if len(restore.Spec.NamespaceMapping) > 0 {
+		remapNamespaces(restore, &objFromBackupCR)
+	}
+	if restore.Spec.RestrictToNamespace != "" {
+		restrictToNamespace(restore, &objFromBackupCR)
+	}
+	if len(restore.Spec.IncludeResourceSelectors) > 0 || len(restore.Spec.ExcludeResourceSelectors) > 0 {
+		if err := selectResources(restore, &objFromBackupCR); err != nil {
+			return h.setReconcilingCondition(restore, fmt.Errorf("error selecting resources to restore: %v", err))
+		}
+	}
 
 	// first stop the controllers
-	h.scaleDownControllersFromResourceSet(objFromBackupCR)
+	h.scaleDownControllersFromResourceSet(restore, objFromBackupCR)
 
 	// first restore CRDs
 	logrus.Infof("Starting to restore CRDs for restore CR %v", restore.Name)
-	if err := h.restoreCRDs(created, objFromBackupCR); err != nil {
-		h.scaleUpControllersFromResourceSet(objFromBackupCR)
+	if err := h.restoreCRDs(restore, created, objFromBackupCR); err != nil {
+		h.scaleUpControllersFromResourceSet(restore, objFromBackupCR)
 		logrus.Errorf("Error restoring CRDs %v", err)
+		h.saveReport(restore)
 		// Cannot set the exact error on reconcile condition, the order in which resources failed to restore are added in err msg could
 		// change with each restore, which means the condition will get updated on each try
-		return h.setReconcilingCondition(restore, fmt.Errorf("error restoring CRDs, check logs for exact error"))
+		return h.setReconcilingCondition(restore, restoreConditionError("error restoring CRDs", err))
 	}
 
-	logrus.Infof("Starting to restore clusterscoped resources for restore CR %v", restore.Name)
-	// then restore clusterscoped resources, by first generating dependency graph for cluster scoped resources, and create from the graph
-	if err := h.restoreClusterScopedResources(ownerToDependentsList, &toRestore, numOwnerReferences, created, objFromBackupCR); err != nil {
-		h.scaleUpControllersFromResourceSet(objFromBackupCR)
-		logrus.Errorf("Error restoring cluster-scoped resources %v", err)
-		return h.setReconcilingCondition(restore, fmt.Errorf("error restoring cluster-scoped resources, check logs for exact error"))
-	}
+	// then restore the objects wave by wave in the order of the resource priority, all in one wave if there is none
+	waves := restoreWaves(resourcePriority, objFromBackupCR)
+	for i, wave := range waves {
+		if len(waves) > 1 {
+			logrus.Infof("Starting to restore wave %v of %v for restore CR %v", i+1, len(waves), restore.Name)
+		}
+		logrus.Infof("Starting to restore clusterscoped resources for restore CR %v", restore.Name)
+		// restore clusterscoped resources, by first generating dependency graph for cluster scoped resources, and create from the graph
+		ownerToDependentsList = make(map[string][]restoreObj)
+		toRestore = []restoreObj{}
+		if err := h.restoreClusterScopedResources(ownerToDependentsList, &toRestore, numOwnerReferences, created, notRestored, wave); err != nil {
+			h.scaleUpControllersFromResourceSet(restore, objFromBackupCR)
+			logrus.Errorf("Error restoring cluster-scoped resources %v", err)
+			h.saveReport(restore)
+			return h.setReconcilingCondition(restore, restoreConditionError("error restoring cluster-scoped resources", err))
+		}
 
-	logrus.Infof("Starting to restore namespaced resources for restore CR %v", restore.Name)
-	// now restore namespaced resources: generate adjacency lists for dependents and ownerRefs for namespaced resources
-	ownerToDependentsList = make(map[string][]restoreObj)
-	toRestore = []restoreObj{}
-	if err := h.restoreNamespacedResources(ownerToDependentsList, &toRestore, numOwnerReferences, created, objFromBackupCR); err != nil {
-		h.scaleUpControllersFromResourceSet(objFromBackupCR)
-		logrus.Errorf("Error restoring namespaced resources %v", err)
-		return h.setReconcilingCondition(restore, fmt.Errorf("error restoring namespaced resources, check logs for exact error"))
+		logrus.Infof("Starting to restore namespaced resources for restore CR %v", restore.Name)
+		// now restore namespaced resources: generate adjacency lists for dependents and ownerRefs for namespaced resources
+		ownerToDependentsList = make(map[string][]restoreObj)
+		toRestore = []restoreObj{}
+		if err := h.restoreNamespacedResources(ownerToDependentsList, &toRestore, numOwnerReferences, created, notRestored, wave); err != nil {
+			h.scaleUpControllersFromResourceSet(restore, objFromBackupCR)
+			logrus.Errorf("Error restoring namespaced resources %v", err)
+			h.saveReport(restore)
+			return h.setReconcilingCondition(restore, restoreConditionError("error restoring namespaced resources", err))
+		}
 	}
 
 	// prune by default
 	if restore.Spec.Prune == nil || *restore.Spec.Prune == true {
 		logrus.Infof("Pruning resources that are not part of the backup for restore CR %v", restore.Name)
-		if err := h.prune(objFromBackupCR.backupResourceSet.ResourceSelectors, transformerMap, objFromBackupCR, restore.Spec.DeleteTimeoutSeconds); err != nil {
-			h.scaleUpControllersFromResourceSet(objFromBackupCR)
+		if err := h.prune(restore, objFromBackupCR.backupResourceSet.ResourceSelectors, transformerMap, objFromBackupCR, restore.Spec.DeleteTimeoutSeconds); err != nil {
+			h.scaleUpControllersFromResourceSet(restore, objFromBackupCR)
 			return h.setReconcilingCondition(restore, fmt.Errorf("error pruning during restore: %v", err))
 		}
 	}
-	h.scaleUpControllersFromResourceSet(objFromBackupCR)
+	if h.plan != nil {
+		planned, err := h.completeDryRun(restore, backupSource)
+		completed = err == nil
+		return planned, err
+	}
+	h.scaleUpControllersFromResourceSet(restore, objFromBackupCR)
 
+	report := h.saveReport(restore)
 	updateErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		restore, err = h.restores.Get(restore.Name, k8sv1.GetOptions{})
 		if err != nil {
@@ -262,26 +438,43 @@ func (h *handler) OnRestoreChange(_ string, restore *v1.Restore) (*v1.Restore, e
 		restore.Status.RestoreCompletionTS = time.Now().Format(time.RFC3339)
 		restore.Status.ObservedGeneration = restore.Generation
 		restore.Status.BackupSource = backupSource
+		restore.Status.Report = report
 		_, err = h.restores.UpdateStatus(restore)
 		return err
 	})
 	if updateErr != nil {
 		return h.setReconcilingCondition(restore, updateErr)
 	}
+	completed = true
+	metrics.RestoreSucceeded(restore.Name, time.Since(restoreStartTime))
+	h.recorder.Eventf(restore, corev1.EventTypeNormal, eventReasonCompleted, "Completed restore from %v in %v", backupToRestore(restore),
+		time.Since(restoreStartTime).Round(time.Millisecond))
 	logrus.Infof("Done restoring")
 	return restore, err
 }
 
-func (h *handler) restoreCRDs(created map[string]bool, objFromBackupCR ObjectsFromBackupCR) error {
+func (h *handler) restoreCRDs(restore *v1.Restore, created map[string]bool, objFromBackupCR ObjectsFromBackupCR) error {
 	for crdInfo, crdData := range objFromBackupCR.crdInfoToData {
 		err := h.restoreResource(crdInfo, crdData, false)
+		if isForbidden(err) {
+			permissionErrors := &resourcesets.PermissionErrors{}
+			permissionErrors.Add(crdInfo.GVR, err)
+			return permissionErrors
+		}
 		if err != nil {
 			return fmt.Errorf("restoreCRDs: %v", err)
 		}
 		created[crdInfo.ConfigPath] = true
 	}
+	if h.plan != nil {
+		// nothing was created to wait for
+		return nil
+	}
 	for crdInfo := range objFromBackupCR.crdInfoToData {
 		if err := h.waitCRD(crdInfo.Name); err != nil {
+			if err == wait.ErrWaitTimeout {
+				h.recorder.Eventf(restore, corev1.EventTypeWarning, eventReasonCRDWaitTimeout, "Timed out waiting for CRD %v to become available", crdInfo.Name)
+			}
 			return err
 		}
 	}
@@ -320,21 +513,21 @@ func (h *handler) waitCRD(crdName string) error {
 }
 
 func (h *handler) restoreClusterScopedResources(ownerToDependentsList map[string][]restoreObj, toRestore *[]restoreObj,
-	numOwnerReferences map[string]int, created map[string]bool, objFromBackupCR ObjectsFromBackupCR) error {
+	numOwnerReferences map[string]int, created map[string]bool, notRestored map[string]error, objFromBackupCR ObjectsFromBackupCR) error {
 	// generate adjacency lists for dependents and ownerRefs first for clusterscoped resources
 	if err := h.generateDependencyGraph(ownerToDependentsList, toRestore, numOwnerReferences, objFromBackupCR, created, clusterScoped); err != nil {
 		return err
 	}
-	return h.createFromDependencyGraph(ownerToDependentsList, created, numOwnerReferences, objFromBackupCR, *toRestore)
+	return h.createFromDependencyGraph(ownerToDependentsList, created, notRestored, numOwnerReferences, objFromBackupCR, *toRestore)
 }
 
 func (h *handler) restoreNamespacedResources(ownerToDependentsList map[string][]restoreObj, toRestore *[]restoreObj,
-	numOwnerReferences map[string]int, created map[string]bool, objFromBackupCR ObjectsFromBackupCR) error {
+	numOwnerReferences map[string]int, created map[string]bool, notRestored map[string]error, objFromBackupCR ObjectsFromBackupCR) error {
 	// generate adjacency lists for dependents and ownerRefs for namespaced resources
 	if err := h.generateDependencyGraph(ownerToDependentsList, toRestore, numOwnerReferences, objFromBackupCR, created, namespaceScoped); err != nil {
 		return err
 	}
-	return h.createFromDependencyGraph(ownerToDependentsList, created, numOwnerReferences, objFromBackupCR, *toRestore)
+	return h.createFromDependencyGraph(ownerToDependentsList, created, notRestored, numOwnerReferences, objFromBackupCR, *toRestore)
 }
 
 // generateDependencyGraph creates a graph "ownerToDependentsList" to track objects with ownerReferences
@@ -429,13 +622,6 @@ func (h *handler) generateDependencyGraph(ownerToDependentsList map[string][]res
 				ResourceConfigPath: filepath.Join(ownerDirPath, ownerName+".json"),
 				GVR:                ownerGVR,
 			}
-			// If we are generating graph for the namespaced resources, and the ownerRef is clusterscoped, it should have been created by now
-			// So we can check its presence in "created" map skip adding this ownerRef to ownerToDependentsList for the current resource
-			if !isOwnerNamespaced {
-				if created[ownerObj.ResourceConfigPath] {
-					continue
-				}
-			}
 			if isOwnerNamespaced {
 				// if owner object is namespaced, then it has to be the same ns as the current dependent object as per k8s design
 				ownerObj.Namespace = currRestoreObj.Namespace
@@ -444,6 +630,16 @@ func (h *handler) generateDependencyGraph(ownerToDependentsList map[string][]res
 				ownerFilename := filepath.Join(currRestoreObj.Namespace, ownerName+".json")
 				ownerObj.ResourceConfigPath = filepath.Join(ownerDirPath, ownerFilename)
 			}
+			// Owners restored in an earlier phase or wave, such as the clusterscoped owners of namespaced resources, have been created by
+			// now, so skip adding this ownerRef to ownerToDependentsList for the current resource
+			if created[ownerObj.ResourceConfigPath] {
+				continue
+			}
+			// An owner that the Restore's resource selectors don't restore has to exist in the cluster already, it is looked up when
+			// restoring the dependent
+			if objFromBackupCR.excludedResources[ownerObj.ResourceConfigPath] {
+				continue
+			}
 			ownerObjDependents, ok := ownerToDependentsList[ownerObj.ResourceConfigPath]
 			if !ok {
 				ownerToDependentsList[ownerObj.ResourceConfigPath] = []restoreObj{currRestoreObj}
@@ -471,67 +667,160 @@ func (h *handler) generateDependencyGraph(ownerToDependentsList map[string][]res
 	return nil
 }
 
+// concurrently returns a copy of the handler that restores the given number of objects at once
+func (h *handler) concurrently(concurrency int) *handler {
+	concurrent := *h
+	concurrent.concurrency = concurrency
+	return &concurrent
+}
+
+// createFromDependencyGraph restores the objects ready to be restored with a pool of workers, and releases the dependents of each
+// object once it is restored and all their other owners are too. Only this function's loop touches the graph, the workers just
+// restore the objects they are handed. Dependents of objects that failed to restore, in this graph or an earlier one, are skipped
 func (h *handler) createFromDependencyGraph(ownerToDependentsList map[string][]restoreObj, created map[string]bool,
-	numOwnerReferences map[string]int, objFromBackupCR ObjectsFromBackupCR, toRestore []restoreObj) error {
-	numTotalDependents := 0
-	for _, dependents := range ownerToDependentsList {
-		numTotalDependents += len(dependents)
+	notRestored map[string]error, numOwnerReferences map[string]int, objFromBackupCR ObjectsFromBackupCR, toRestore []restoreObj) error {
+	type restoreResult struct {
+		obj restoreObj
+		err error
 	}
-	countRestored := 0
-	var errList []error
-	for len(toRestore) > 0 {
-		curr := toRestore[0]
-		if len(toRestore) == 1 {
-			toRestore = []restoreObj{}
-		} else {
-			toRestore = toRestore[1:]
-		}
-		if created[curr.ResourceConfigPath] {
-			logrus.Infof("Resource %v is already created/updated", curr.ResourceConfigPath)
-			continue
-		}
-		currResourceInfo := objInfo{
-			Name:       curr.Name,
-			Namespace:  curr.Namespace,
-			GVR:        curr.GVR,
-			ConfigPath: curr.ResourceConfigPath,
-		}
-		var resourceData unstructured.Unstructured
-		if curr.Namespace != "" {
-			resourceData = objFromBackupCR.namespacedResourceInfoToData[currResourceInfo]
-		} else {
-			resourceData = objFromBackupCR.clusterscopedResourceInfoToData[currResourceInfo]
-		}
-		if err := h.restoreResource(currResourceInfo, resourceData, objFromBackupCR.resourcesWithStatusSubresource[curr.GVR.String()]); err != nil {
-			logrus.Errorf("Error restoring resource %v of type %v: %v", currResourceInfo.Name, currResourceInfo.GVR.String(), err)
-			errList = append(errList, fmt.Errorf("error restoring %v of type %v: %v", currResourceInfo.Name, currResourceInfo.GVR.String(), err))
-			continue
+	workers := h.concurrency
+	if workers <= 0 {
+		workers = util.WorkerThreads
+	}
+	queue := make(chan restoreObj)
+	results := make(chan restoreResult)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for curr := range queue {
+				currResourceInfo := objInfo{
+					Name:       curr.Name,
+					Namespace:  curr.Namespace,
+					GVR:        curr.GVR,
+					ConfigPath: curr.ResourceConfigPath,
+				}
+				var resourceData unstructured.Unstructured
+				if curr.Namespace != "" {
+					resourceData = objFromBackupCR.namespacedResourceInfoToData[currResourceInfo]
+				} else {
+					resourceData = objFromBackupCR.clusterscopedResourceInfoToData[currResourceInfo]
+				}
+				err := h.restoreResource(currResourceInfo, resourceData, objFromBackupCR.resourcesWithStatusSubresource[curr.GVR.String()])
+				results <- restoreResult{obj: curr, err: err}
+			}
+		}()
+	}
+
+	inProgress := make(map[string]bool)
+	// errors are keyed by the path of the object, so they are reported in the same order however the workers finish
+	failed := make(map[string]error)
+	permissionErrors := &resourcesets.PermissionErrors{}
+	for len(toRestore) > 0 || len(inProgress) > 0 {
+		var next restoreObj
+		var nextQueue chan restoreObj
+		if len(toRestore) > 0 {
+			next = toRestore[0]
+			if created[next.ResourceConfigPath] || inProgress[next.ResourceConfigPath] {
+				logrus.Infof("Resource %v is already created/updated", next.ResourceConfigPath)
+				toRestore = toRestore[1:]
+				continue
+			}
+			nextQueue = queue
 		}
-		for _, dependent := range ownerToDependentsList[curr.ResourceConfigPath] {
-			// example, curr = catTemplate, dependent=catTempVer
-			if numOwnerReferences[dependent.ResourceConfigPath] > 0 {
-				numOwnerReferences[dependent.ResourceConfigPath]--
+		select {
+		case nextQueue <- next:
+			toRestore = toRestore[1:]
+			inProgress[next.ResourceConfigPath] = true
+		case result := <-results:
+			curr := result.obj
+			delete(inProgress, curr.ResourceConfigPath)
+			if result.err != nil {
+				logrus.Errorf("Error restoring resource %v of type %v: %v", curr.Name, curr.GVR.String(), result.err)
+				if isForbidden(result.err) {
+					permissionErrors.Add(curr.GVR, result.err)
+				}
+				failed[curr.ResourceConfigPath] = fmt.Errorf("error restoring %v of type %v: %v", curr.Name, curr.GVR.String(), result.err)
+				notRestored[curr.ResourceConfigPath] = failed[curr.ResourceConfigPath]
+				continue
 			}
-			if numOwnerReferences[dependent.ResourceConfigPath] == 0 {
-				logrus.Infof("dependent %v is now ready to create", dependent.Name)
-				toRestore = append(toRestore, dependent)
+			for _, dependent := range ownerToDependentsList[curr.ResourceConfigPath] {
+				// example, curr = catTemplate, dependent=catTempVer
+				if numOwnerReferences[dependent.ResourceConfigPath] > 0 {
+					numOwnerReferences[dependent.ResourceConfigPath]--
+				}
+				if numOwnerReferences[dependent.ResourceConfigPath] == 0 {
+					logrus.Infof("dependent %v is now ready to create", dependent.Name)
+					toRestore = append(toRestore, dependent)
+				}
 			}
+			created[curr.ResourceConfigPath] = true
 		}
-		created[curr.ResourceConfigPath] = true
-		countRestored++
 	}
+	close(queue)
 
-	if len(toRestore) > 0 {
-		// These resources could not be restored because of some issues with ownerRefs that violate k8s design
-		for _, res := range toRestore {
-			logrus.Warnf("Could not restore %v of type %v", res.Name, res.GVR.String())
+	// dependents of objects that failed to restore, here or in an earlier phase or wave, were left waiting for them, so they are
+	// reported as skipped along with them
+	var failedOwners []string
+	for owner := range ownerToDependentsList {
+		if _, ok := notRestored[owner]; ok {
+			failedOwners = append(failedOwners, owner)
+		}
+	}
+	sort.Strings(failedOwners)
+	for len(failedOwners) > 0 {
+		owner := failedOwners[0]
+		failedOwners = failedOwners[1:]
+		for _, dependent := range ownerToDependentsList[owner] {
+			if _, ok := notRestored[dependent.ResourceConfigPath]; ok || created[dependent.ResourceConfigPath] {
+				continue
+			}
+			logrus.Errorf("Skipped restoring resource %v of type %v, its owner %v was not restored", dependent.Name, dependent.GVR.String(), owner)
+			failed[dependent.ResourceConfigPath] = fmt.Errorf("skipped %v of type %v: owner %v was not restored", dependent.Name,
+				dependent.GVR.String(), owner)
+			notRestored[dependent.ResourceConfigPath] = failed[dependent.ResourceConfigPath]
+			failedOwners = append(failedOwners, dependent.ResourceConfigPath)
+		}
+	}
+	// the other dependents still waiting have an owner that isn't in the backup file
+	warned := make(map[string]bool)
+	for _, dependents := range ownerToDependentsList {
+		for _, dependent := range dependents {
+			path := dependent.ResourceConfigPath
+			if _, ok := notRestored[path]; ok || created[path] || warned[path] || numOwnerReferences[path] == 0 {
+				continue
+			}
+			logrus.Warnf("Could not restore %v of type %v", dependent.Name, dependent.GVR.String())
+			warned[path] = true
 		}
 	}
 
+	paths := make([]string, 0, len(failed))
+	for path := range failed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var errList []error
+	for _, path := range paths {
+		errList = append(errList, failed[path])
+	}
+	if permissionErrors.Len() > 0 {
+		// missing permissions are reported per resource type on the Restore, the errors of each object only in its logs
+		return fmt.Errorf("%w: %v", permissionErrors, util.ErrList(errList))
+	}
 	return util.ErrList(errList)
 }
 
 func (h *handler) restoreResource(restoreObjInfo objInfo, restoreObjData unstructured.Unstructured, hasStatusSubresource bool) error {
+	var transformations []string
+	if len(h.transformations) > 0 {
+		var err error
+		restoreObjData, transformations, err = h.transform(restoreObjInfo, restoreObjData)
+		if err != nil {
+			return err
+		}
+	}
+	if h.plan != nil {
+		return h.planResource(restoreObjInfo, restoreObjData, hasStatusSubresource, transformations)
+	}
 	logrus.Infof("restoreResource: Restoring %v of type %v", restoreObjInfo.Name, restoreObjInfo.GVR)
 
 	fileMap := restoreObjData.Object
@@ -567,40 +856,84 @@ func (h *handler) restoreResource(restoreObjInfo objInfo, restoreObjData unstruc
 	res, err := dr.Get(h.ctx, name, k8sv1.GetOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			return fmt.Errorf("restoreResource: err getting resource %v", err)
+			return fmt.Errorf("restoreResource: err getting resource %w", err)
+		}
+		h.report.add(restoreObjInfo, transformations, nil)
+		if h.applyOptions != nil {
+			err = h.applyResource(dr, obj, hasStatusSubresource)
+		} else {
+			err = h.createResource(dr, obj, gvr, hasStatusSubresource)
 		}
-		// create and return
-		createdObj, err := dr.Create(h.ctx, &obj, k8sv1.CreateOptions{})
 		if err != nil {
 			return err
 		}
-		if hasStatusSubresource {
-			logrus.Infof("Post-create: Updating status subresource for %#v of type %v", name, gvr)
-			createdObj.Object["status"] = obj.Object["status"]
-			_, err := dr.UpdateStatus(h.ctx, createdObj, k8sv1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("restoreResource: err updating status resource %v", err)
-			}
-		}
+		metrics.ObjectRestored(gvr)
+		return nil
+	}
+	objConflict := h.conflicts.resolve(gvr, res)
+	entry := h.report.add(restoreObjInfo, transformations, &objConflict)
+	switch objConflict.Decision {
+	case conflictDecisionSkipped:
+		logrus.Infof("Skipping %v of type %v, it already exists and the conflict policy is %v", name, gvr, objConflict.Policy)
 		return nil
+	case conflictDecisionFailed:
+		return fmt.Errorf("restoreResource: %v already exists and the conflict policy is %v", name, objConflict.Policy)
 	}
+	if h.applyOptions != nil {
+		err = h.applyResource(dr, obj, hasStatusSubresource)
+	} else {
+		err = h.updateResource(dr, res, obj, gvr, hasStatusSubresource)
+	}
+	if err != nil && h.mayRecreate(obj, err) {
+		logrus.Infof("Recreating %v of type %v, its immutable fields differ from the backup: %v", name, gvr, err)
+		if err = h.recreateResource(dr, res, obj, gvr, hasStatusSubresource); err == nil {
+			h.report.recreated(entry)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// objects skipped by the conflict policy or only planned in a dry run returned earlier, so only written objects are counted
+	metrics.ObjectRestored(gvr)
+	logrus.Infof("Successfully restored %v", name)
+	return nil
+}
+
+func (h *handler) createResource(dr dynamic.ResourceInterface, obj unstructured.Unstructured, gvr schema.GroupVersionResource,
+	hasStatusSubresource bool) error {
+	createdObj, err := dr.Create(h.ctx, &obj, k8sv1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if hasStatusSubresource {
+		logrus.Infof("Post-create: Updating status subresource for %#v of type %v", obj.GetName(), gvr)
+		createdObj.Object["status"] = obj.Object["status"]
+		_, err := dr.UpdateStatus(h.ctx, createdObj, k8sv1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("restoreResource: err updating status resource %w", err)
+		}
+	}
+	return nil
+}
+
+func (h *handler) updateResource(dr dynamic.ResourceInterface, res *unstructured.Unstructured, obj unstructured.Unstructured,
+	gvr schema.GroupVersionResource, hasStatusSubresource bool) error {
 	resMetadata := res.Object[metadataMapKey].(map[string]interface{})
 	resourceVersion := resMetadata["resourceVersion"].(string)
 	obj.Object[metadataMapKey].(map[string]interface{})["resourceVersion"] = resourceVersion
 	updatedObj, err := dr.Update(h.ctx, &obj, k8sv1.UpdateOptions{})
 	if err != nil {
-		return fmt.Errorf("restoreResource: err updating resource %v", err)
+		return fmt.Errorf("restoreResource: err updating resource %w", err)
 	}
 	if hasStatusSubresource {
-		logrus.Infof("Updating status subresource for %#v of type %v", name, gvr)
+		logrus.Infof("Updating status subresource for %#v of type %v", obj.GetName(), gvr)
 		updatedObj.Object["status"] = obj.Object["status"]
 		_, err := dr.UpdateStatus(h.ctx, updatedObj, k8sv1.UpdateOptions{})
 		if err != nil {
-			return fmt.Errorf("restoreResource: err updating status resource %v", err)
+			return fmt.Errorf("restoreResource: err updating status resource %w", err)
 		}
 	}
-
-	logrus.Infof("Successfully restored %v", name)
 	return nil
 }
 
@@ -671,24 +1004,10 @@ func (h *handler) getOwnerNewUID(owner *restoreObj) (string, error) {
 	return ownerObjUID, nil
 }
 
-// getGVR parses the directory path to provide groupVersionResource
-func getGVR(resourceGVR string) schema.GroupVersionResource {
-	gvkParts := strings.Split(resourceGVR, "#")
-	version := gvkParts[1]
-	resourceGroup := strings.SplitN(gvkParts[0], ".", 2)
-	resource := strings.TrimSuffix(resourceGroup[0], ".")
-	var group string
-	if len(resourceGroup) > 1 {
-		group = resourceGroup[1]
-	}
-	gr := schema.ParseGroupResource(resource + "." + group)
-	gvr := gr.WithVersion(version)
-	return gvr
-}
-
 // https://github.com/kubernetes-sigs/cli-utils/tree/master/pkg/kstatus
 // Reconciling and Stalled conditions are present and with a value of true whenever something unusual happens.
 func (h *handler) setReconcilingCondition(restore *v1.Restore, originalErr error) (*v1.Restore, error) {
+	h.recorder.Event(restore, corev1.EventTypeWarning, eventReasonFailed, originalErr.Error())
 	if !condition.Cond(v1.RestoreConditionReconciling).IsUnknown(restore) && condition.Cond(v1.RestoreConditionReconciling).GetReason(restore) == "Error" {
 		reconcileMsg := condition.Cond(v1.RestoreConditionReconciling).GetMessage(restore)
 		if strings.Contains(reconcileMsg, originalErr.Error()) || strings.EqualFold(reconcileMsg, originalErr.Error()) {
@@ -738,12 +1057,31 @@ func (h *handler) Lock(restore *v1.Restore) error {
 		return err
 	}
 
-	if lease.Spec.HolderIdentity != nil {
-		return fmt.Errorf("restore %v is in progress", *lease.Spec.HolderIdentity)
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != *leaseHolderName(restore) {
+		if !h.isStaleLeaseHolder(*lease.Spec.HolderIdentity) {
+			return fmt.Errorf("restore %v is in progress", *lease.Spec.HolderIdentity)
+		}
+		logrus.Infof("Taking over lease %v from restore %v, it was interrupted and no longer needs it", leaseName, *lease.Spec.HolderIdentity)
 	}
+	// the update fails with a conflict if another restore took the lease since it was read
 	return h.updateLeaseHolderIdentity(restore, lease)
 }
 
+// isStaleLeaseHolder returns true if the restore holding the lease was deleted or has completed, which happens when the operator
+// that was running it crashed or lost leadership before unlocking. A restore that still has to run keeps the lease, it is picked
+// up again by the current leader and re-acquires the lease it already holds
+func (h *handler) isStaleLeaseHolder(id string) bool {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 {
+		return true
+	}
+	holder, err := h.restores.Get(parts[0], k8sv1.GetOptions{})
+	if err != nil {
+		return apierrors.IsNotFound(err)
+	}
+	return string(holder.UID) != parts[1] || holder.DeletionTimestamp != nil || holder.Status.RestoreCompletionTS != ""
+}
+
 func (h *handler) updateLeaseHolderIdentity(restore *v1.Restore, lease *coordinationv1.Lease) error {
 	lease.Spec.HolderIdentity = leaseHolderName(restore)
 	_, err := h.kubernetesLeaseClient.Update(h.ctx, lease, k8sv1.UpdateOptions{})
@@ -769,6 +1107,34 @@ func (h *handler) Unlock(id string) error {
 	return err
 }
 
+// backupToRestore describes the backup file the Restore restores from in events and logs
+func backupToRestore(restore *v1.Restore) string {
+	if len(restore.Spec.BackupTagSelector) > 0 {
+		if restore.Status.BackupFilename != "" {
+			return fmt.Sprintf("%v, the newest backup file with tags %v", restore.Status.BackupFilename, restore.Spec.BackupTagSelector)
+		}
+		return fmt.Sprintf("newest backup file with tags %v", restore.Spec.BackupTagSelector)
+	}
+	return restore.Spec.BackupFilename
+}
+
+// recordBackupFilename records the backup file the backupTagSelector selected in the Restore's status
+func (h *handler) recordBackupFilename(restore *v1.Restore, filename string) (*v1.Restore, error) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updRestore, err := h.restores.Get(restore.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updRestore.Status.BackupFilename = filename
+		updRestore, err = h.restores.UpdateStatus(updRestore)
+		if err == nil {
+			restore = updRestore
+		}
+		return err
+	})
+	return restore, err
+}
+
 func leaseHolderName(restore *v1.Restore) *string {
 	return pointer.StringPtr(fmt.Sprintf("%s:%s", restore.Name, string(restore.UID)))
 }
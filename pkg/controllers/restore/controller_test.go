@@ -0,0 +1,139 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// configMapGraph is a dependency graph of ConfigMaps in the app namespace, keyed by name, listing the owners of each
+type configMapGraph map[string][]string
+
+func (g configMapGraph) restoreObj(name string) restoreObj {
+	return restoreObj{
+		Name:               name,
+		Namespace:          "app",
+		ResourceConfigPath: "configmaps.#v1/app/" + name + ".json",
+		GVR:                configMapsGVR,
+	}
+}
+
+// build returns the arguments of createFromDependencyGraph for the graph, as generateDependencyGraph would
+func (g configMapGraph) build() (map[string][]restoreObj, map[string]int, ObjectsFromBackupCR, []restoreObj) {
+	ownerToDependentsList := make(map[string][]restoreObj)
+	numOwnerReferences := make(map[string]int)
+	objFromBackupCR := newObjectsFromBackupCR()
+	var toRestore []restoreObj
+	var names []string
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		curr := g.restoreObj(name)
+		obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace(curr.Namespace)
+		obj.SetName(name)
+		objFromBackupCR.namespacedResourceInfoToData[objInfo{Name: name, Namespace: curr.Namespace, GVR: curr.GVR,
+			ConfigPath: curr.ResourceConfigPath}] = obj
+		for _, owner := range g[name] {
+			ownerPath := g.restoreObj(owner).ResourceConfigPath
+			ownerToDependentsList[ownerPath] = append(ownerToDependentsList[ownerPath], curr)
+		}
+		if len(g[name]) == 0 {
+			toRestore = append(toRestore, curr)
+		} else {
+			numOwnerReferences[curr.ResourceConfigPath] = len(g[name])
+		}
+	}
+	return ownerToDependentsList, numOwnerReferences, objFromBackupCR, toRestore
+}
+
+func TestCreateFromDependencyGraph(t *testing.T) {
+	graph := configMapGraph{
+		"a":      nil,
+		"b":      {"a"},
+		"c":      {"a", "d"},
+		"d":      nil,
+		"e":      {"c"},
+		"broken": nil,
+		"f":      {"broken"},
+		"g":      {"f"},
+		"h":      {"b", "f"},
+	}
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "default concurrency"},
+		{name: "one worker", concurrency: 1},
+		{name: "more workers than objects", concurrency: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+			var mu sync.Mutex
+			createdOrder := make(map[string]int)
+			var creates []string
+			client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+				if obj.GetName() == "broken" {
+					return true, nil, fmt.Errorf("invalid")
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				createdOrder[obj.GetName()] = len(creates)
+				creates = append(creates, obj.GetName())
+				return false, nil, nil
+			})
+			h := &handler{ctx: context.Background(), dynamicClient: client, concurrency: tt.concurrency}
+
+			ownerToDependentsList, numOwnerReferences, objFromBackupCR, toRestore := graph.build()
+			created := make(map[string]bool)
+			notRestored := make(map[string]error)
+			err := h.createFromDependencyGraph(ownerToDependentsList, created, notRestored, numOwnerReferences, objFromBackupCR, toRestore)
+			if err == nil {
+				t.Errorf("createFromDependencyGraph() error = nil, want the objects that failed")
+			}
+
+			sort.Strings(creates)
+			if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(creates, want) {
+				t.Errorf("createFromDependencyGraph() created %v, want %v once each", creates, want)
+			}
+			for name, owners := range graph {
+				for _, owner := range owners {
+					if _, ok := createdOrder[name]; ok && createdOrder[owner] > createdOrder[name] {
+						t.Errorf("createFromDependencyGraph() created %v before its owner %v", name, owner)
+					}
+				}
+			}
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				if !created[graph.restoreObj(name).ResourceConfigPath] {
+					t.Errorf("createFromDependencyGraph() didn't mark %v as created", name)
+				}
+			}
+			var gotNotRestored []string
+			for path := range notRestored {
+				gotNotRestored = append(gotNotRestored, path)
+			}
+			sort.Strings(gotNotRestored)
+			var wantNotRestored []string
+			for _, name := range []string{"broken", "f", "g", "h"} {
+				wantNotRestored = append(wantNotRestored, graph.restoreObj(name).ResourceConfigPath)
+			}
+			if !reflect.DeepEqual(gotNotRestored, wantNotRestored) {
+				t.Errorf("createFromDependencyGraph() didn't restore %v, want %v", gotNotRestored, wantNotRestored)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// impersonating returns a copy of the handler whose clients for restoring and pruning resources and scaling controllers act as the
+// Restore's serviceAccountName. Credentials of storage locations are still read as the operator
+func (h *handler) impersonating(restore *v1.Restore) (*handler, error) {
+	config := util.ServiceAccountConfig(h.restConfig, restore.Spec.RestrictToNamespace, restore.Spec.ServiceAccountName)
+	logrus.Debugf("Processing restore CR %v as %v", restore.Name, config.Impersonate.UserName)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	apiClient, err := clientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	impersonating := *h
+	impersonating.discoveryClient = discoveryClient
+	impersonating.dynamicClient = dynamicClient
+	impersonating.apiClient = apiClient
+	return &impersonating, nil
+}
+
+// isForbidden also checks the errors wrapped by restoreResource
+func isForbidden(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsForbidden(errors.Unwrap(err))
+}
+
+// restoreConditionError returns the error to set on the Reconciling condition when restoring resources failed. The errors of
+// individual objects are only logged, because the order in which they fail changes with each try and the condition would get
+// updated each time, but missing permissions are reported per resource type
+func restoreConditionError(msg string, err error) error {
+	var permissionErrors *resourcesets.PermissionErrors
+	if errors.As(err, &permissionErrors) {
+		return fmt.Errorf("%v: %v", msg, permissionErrors)
+	}
+	return fmt.Errorf("%v, check logs for exact error", msg)
+}
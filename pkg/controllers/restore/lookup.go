@@ -0,0 +1,262 @@
+package restore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v6"
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/objectstore"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// backupFilePathInMountPath returns the path of the backup file to restore from the mount path. If backupFilename isn't a path
+// relative to the mount path, the backup file is looked up by its metadata file, since the Backup's filename template may have put it
+// in a folder
+func backupFilePathInMountPath(restore *v1.Restore, mountPath string) (string, error) {
+	backupFilePath := filepath.Join(mountPath, backupFilename(restore))
+	if _, err := os.Stat(backupFilePath); !os.IsNotExist(err) {
+		return backupFilePath, nil
+	}
+	metadataName := path.Base(util.MetadataFilename(backupFilename(restore)))
+	var found []*util.BackupMetadata
+	err := filepath.Walk(mountPath, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() || fileInfo.Name() != metadataName {
+			return nil
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		metadata, err := util.ParseBackupMetadata(data)
+		if err != nil {
+			return err
+		}
+		found = append(found, metadata)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	metadata, err := singleBackupMetadata(restore, found)
+	if err != nil || metadata == nil {
+		// let loading the backup file fail with the path the user asked for
+		return backupFilePath, err
+	}
+	return filepath.Join(mountPath, metadata.Filename), nil
+}
+
+// backupObjectKeyInS3 returns the key of the backup file to restore from the bucket, looked up by its metadata file like
+// backupFilePathInMountPath if backupFilename isn't a key relative to the folder
+func backupObjectKeyInS3(restore *v1.Restore, client *minio.Client, objStore *v1.S3ObjectStore) (string, error) {
+	prefix := ""
+	if objStore.Folder != "" {
+		prefix = objStore.Folder + "/"
+	}
+	key := prefix + backupFilename(restore)
+	if _, err := client.StatObject(objStore.BucketName, key, minio.StatObjectOptions{}); err == nil {
+		return key, nil
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	metadataName := path.Base(util.MetadataFilename(backupFilename(restore)))
+	var found []*util.BackupMetadata
+	for object := range client.ListObjects(objStore.BucketName, prefix, true, doneCh) {
+		if object.Err != nil {
+			return "", object.Err
+		}
+		if path.Base(object.Key) != metadataName {
+			continue
+		}
+		metadataObject, err := client.GetObject(objStore.BucketName, object.Key, minio.GetObjectOptions{})
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadAll(metadataObject)
+		metadataObject.Close()
+		if err != nil {
+			return "", err
+		}
+		metadata, err := util.ParseBackupMetadata(data)
+		if err != nil {
+			return "", err
+		}
+		found = append(found, metadata)
+	}
+	metadata, err := singleBackupMetadata(restore, found)
+	if err != nil || metadata == nil {
+		return key, err
+	}
+	return prefix + metadata.Filename, nil
+}
+
+func singleBackupMetadata(restore *v1.Restore, found []*util.BackupMetadata) (*util.BackupMetadata, error) {
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		var filenames []string
+		for _, metadata := range found {
+			filenames = append(filenames, metadata.Filename)
+		}
+		return nil, fmt.Errorf("found multiple backup files named %v: %v, specify its path in backupFilename", backupFilename(restore), filenames)
+	}
+	metadata := found[0]
+	logrus.Infof("Found backup file %v of backup CR %v for restore CR %v", metadata.Filename, metadata.BackupName, restore.Name)
+	if metadata.Encrypted && restore.Spec.EncryptionConfigSecretName == "" {
+		return nil, fmt.Errorf("backup file %v is encrypted, encryptionConfigSecretName must be set to restore it", metadata.Filename)
+	}
+	return metadata, nil
+}
+
+// backupFilename is the backup file to restore, the one the backupTagSelector selected when the Restore has one
+func backupFilename(restore *v1.Restore) string {
+	if len(restore.Spec.BackupTagSelector) > 0 {
+		return restore.Status.BackupFilename
+	}
+	return restore.Spec.BackupFilename
+}
+
+// selectBackupFile returns the newest backup file with all tags of the Restore's backupTagSelector in the storage location the
+// Restore restores from, relative to it. It is selected once and recorded in the Restore's status, so that the restore isn't retried
+// from a newer backup file than the one it was partly restored from
+func (h *handler) selectBackupFile(restore *v1.Restore) (string, error) {
+	objStore := h.defaultS3BackupLocation
+	if restore.Spec.StorageLocation != nil {
+		objStore = restore.Spec.StorageLocation.S3
+	} else if objStore == nil && h.defaultBackupMountPath != "" {
+		metadata, err := newestBackupInMountPath(restore, h.defaultBackupMountPath)
+		if err != nil {
+			return "", err
+		}
+		return metadata.Filename, nil
+	}
+	if objStore == nil {
+		return "", fmt.Errorf("Backup location not specified on the restore CR, and not configured at the operator level")
+	}
+	client, err := objectstore.GetS3Client(h.ctx, objStore, h.storageDynamicClient)
+	if err != nil {
+		return "", err
+	}
+	prefix := ""
+	if objStore.Folder != "" {
+		prefix = objStore.Folder + "/"
+	}
+	metadata, err := newestBackupInS3(restore, client, objStore, prefix)
+	if err != nil {
+		return "", err
+	}
+	return metadata.Filename, nil
+}
+
+// newestBackupInMountPath returns the metadata of the newest backup file in the mount path with all tags of the Restore's
+// backupTagSelector, as recorded in the metadata files
+func newestBackupInMountPath(restore *v1.Restore, mountPath string) (*util.BackupMetadata, error) {
+	var found []*util.BackupMetadata
+	err := filepath.Walk(mountPath, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() || !util.IsMetadataFilename(fileInfo.Name()) {
+			return nil
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		metadata, err := util.ParseBackupMetadata(data)
+		if err != nil {
+			return err
+		}
+		found = append(found, metadata)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newestMatchingBackup(restore, found)
+}
+
+// newestBackupInS3 returns the metadata of the newest backup file in the bucket with all tags of the Restore's backupTagSelector.
+// The tags are read from the metadata files, or from the object tags of backup files without one
+func newestBackupInS3(restore *v1.Restore, client *minio.Client, objStore *v1.S3ObjectStore, prefix string) (*util.BackupMetadata, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	keys := make(map[string]bool)
+	for object := range client.ListObjects(objStore.BucketName, prefix, true, doneCh) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		keys[object.Key] = true
+	}
+	var found []*util.BackupMetadata
+	for key := range keys {
+		if !strings.HasSuffix(key, ".tar.gz") && !strings.HasSuffix(key, ".tar.gz.enc") {
+			continue
+		}
+		if keys[util.MetadataFilename(key)] {
+			metadataObject, err := client.GetObject(objStore.BucketName, util.MetadataFilename(key), minio.GetObjectOptions{})
+			if err != nil {
+				return nil, err
+			}
+			data, err := ioutil.ReadAll(metadataObject)
+			metadataObject.Close()
+			if err != nil {
+				return nil, err
+			}
+			metadata, err := util.ParseBackupMetadata(data)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, metadata)
+			continue
+		}
+		tags, err := objectstore.GetObjectTags(client, objStore.BucketName, key)
+		if err != nil {
+			logrus.Warnf("Error reading tags of backup file %v: %v", key, err)
+			continue
+		}
+		if metadata := util.BackupMetadataFromTags(strings.TrimPrefix(key, prefix), tags); metadata != nil {
+			found = append(found, metadata)
+		}
+	}
+	return newestMatchingBackup(restore, found)
+}
+
+func newestMatchingBackup(restore *v1.Restore, found []*util.BackupMetadata) (*util.BackupMetadata, error) {
+	var newest *util.BackupMetadata
+	var newestCreatedAt time.Time
+	for _, metadata := range found {
+		if !metadata.MatchesTags(restore.Spec.BackupTagSelector) {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, metadata.CreatedAt)
+		if err != nil {
+			logrus.Warnf("Invalid creation time %v of backup file %v, skipping it", metadata.CreatedAt, metadata.Filename)
+			continue
+		}
+		if newest == nil || createdAt.After(newestCreatedAt) {
+			newest, newestCreatedAt = metadata, createdAt
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no backup file found with tags %v", restore.Spec.BackupTagSelector)
+	}
+	logrus.Infof("Found backup file %v of backup CR %v with tags %v for restore CR %v", newest.Filename, newest.BackupName,
+		restore.Spec.BackupTagSelector, restore.Name)
+	if newest.Encrypted && restore.Spec.EncryptionConfigSecretName == "" {
+		return nil, fmt.Errorf("backup file %v is encrypted, encryptionConfigSecretName must be set to restore it", newest.Filename)
+	}
+	return newest, nil
+}
@@ -0,0 +1,172 @@
+package restore
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// namespaceNameLabel is set on every Namespace by the apiserver to its name
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// namespaceReferences are the fields of namespaced objects that refer to namespaces other than their own, as the path to the namespace
+// field. A "*" in the path stands for every item of a list. The references of cluster-scoped objects, such as the subjects of
+// ClusterRoleBindings or the services of webhook configurations, are never rewritten: they are shared by the namespaces mapped from
+// and to, and restoring them pointed at the target would break the source namespace when cloning it within the same cluster
+var namespaceReferences = map[schema.GroupResource][][]string{
+	{Group: "rbac.authorization.k8s.io", Resource: "rolebindings"}: {{"subjects", "*", "namespace"}},
+}
+
+func validateNamespaceMapping(mapping map[string]string) error {
+	targets := make(map[string]string)
+	for source, target := range mapping {
+		for _, namespace := range []string{source, target} {
+			if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+				return fmt.Errorf("invalid namespace %v in namespaceMapping: %v", namespace, strings.Join(errs, ", "))
+			}
+		}
+		if otherSource, ok := targets[target]; ok {
+			return fmt.Errorf("namespaceMapping maps both %v and %v to %v", source, otherSource, target)
+		}
+		targets[target] = source
+	}
+	return nil
+}
+
+// remapNamespaces moves the namespaced objects read from the backup file into the namespaces given by the Restore's namespaceMapping,
+// renames the mapped Namespaces, and rewrites the references of the moved objects to mapped namespaces. It is done after loading the backup file, so that
+// encrypted objects are decrypted with their original namespace, which is part of their additional authenticated data
+func remapNamespaces(restore *v1.Restore, cr *ObjectsFromBackupCR) {
+	mapping := restore.Spec.NamespaceMapping
+	remapped := 0
+	// build new maps rather than changing them while iterating, a mapped namespace may be the source of another mapping
+	namespacedResourceInfoToData := make(map[objInfo]unstructured.Unstructured)
+	for info, data := range cr.namespacedResourceInfoToData {
+		if target, ok := mapping[info.Namespace]; ok {
+			remapNamespaceReferences(info.GVR, &data, mapping)
+			delete(cr.resourcesFromBackup, info.ConfigPath)
+			// resourceDir/namespace/name.json
+			parts := strings.Split(info.ConfigPath, "/")
+			parts[len(parts)-2] = target
+			info.ConfigPath = strings.Join(parts, "/")
+			info.Namespace = target
+			data.SetNamespace(target)
+			remapped++
+		}
+		namespacedResourceInfoToData[info] = data
+	}
+	clusterscopedResourceInfoToData := make(map[objInfo]unstructured.Unstructured)
+	for info, data := range cr.clusterscopedResourceInfoToData {
+		if target, ok := mapping[info.Name]; ok && info.GVR.Group == "" && info.GVR.Resource == "namespaces" {
+			delete(cr.resourcesFromBackup, info.ConfigPath)
+			info.ConfigPath = path.Join(path.Dir(info.ConfigPath), target+".json")
+			info.Name = target
+			data.SetName(target)
+			if labels := data.GetLabels(); labels[namespaceNameLabel] != "" {
+				labels[namespaceNameLabel] = target
+				data.SetLabels(labels)
+			}
+			remapped++
+		}
+		clusterscopedResourceInfoToData[info] = data
+	}
+	for info := range namespacedResourceInfoToData {
+		cr.resourcesFromBackup[info.ConfigPath] = true
+	}
+	for info := range clusterscopedResourceInfoToData {
+		cr.resourcesFromBackup[info.ConfigPath] = true
+	}
+	cr.namespacedResourceInfoToData = namespacedResourceInfoToData
+	cr.clusterscopedResourceInfoToData = clusterscopedResourceInfoToData
+	for i, controllerRef := range cr.backupResourceSet.ControllerReferences {
+		if target, ok := mapping[controllerRef.Namespace]; ok {
+			cr.backupResourceSet.ControllerReferences[i].Namespace = target
+		}
+	}
+	logrus.Infof("Moved %v resources into other namespaces following the namespaceMapping of restore CR %v", remapped, restore.Name)
+}
+
+// remapResourceSelectors returns the resource selectors of the backup for pruning the namespaces that the namespaceMapping moves objects
+// into: mapped namespaces are replaced by their targets, and the targets of the mapped namespaces a namespaceRegexp matches are added
+func remapResourceSelectors(selectors []v1.ResourceSelector, mapping map[string]string) []v1.ResourceSelector {
+	var sources []string
+	for source := range mapping {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	remapped := make([]v1.ResourceSelector, 0, len(selectors))
+	for _, selector := range selectors {
+		selector = *selector.DeepCopy()
+		for i, namespace := range selector.Namespaces {
+			if target, ok := mapping[namespace]; ok {
+				selector.Namespaces[i] = target
+			}
+		}
+		if selector.NamespaceRegexp != "" {
+			// an invalid regexp fails gathering the objects to prune
+			if namespaceRegexp, err := regexp.Compile(selector.NamespaceRegexp); err == nil {
+				for _, source := range sources {
+					if namespaceRegexp.MatchString(source) {
+						selector.Namespaces = append(selector.Namespaces, mapping[source])
+					}
+				}
+			}
+		}
+		remapped = append(remapped, selector)
+	}
+	return remapped
+}
+
+// mappedAway returns true for the namespaces that the namespaceMapping moves objects out of and none into, the objects left in them
+// aren't restored and must not be pruned
+func mappedAway(mapping map[string]string, namespace string) bool {
+	if _, ok := mapping[namespace]; !ok {
+		return false
+	}
+	for _, target := range mapping {
+		if target == namespace {
+			return false
+		}
+	}
+	return true
+}
+
+func remapNamespaceReferences(gvr schema.GroupVersionResource, data *unstructured.Unstructured, mapping map[string]string) {
+	for _, fieldPath := range namespaceReferences[gvr.GroupResource()] {
+		remapNamespaceField(data.Object, fieldPath, mapping)
+	}
+}
+
+func remapNamespaceField(obj interface{}, fieldPath []string, mapping map[string]string) {
+	if len(fieldPath) == 0 {
+		return
+	}
+	if fieldPath[0] == "*" {
+		items, _ := obj.([]interface{})
+		for _, item := range items {
+			remapNamespaceField(item, fieldPath[1:], mapping)
+		}
+		return
+	}
+	fields, ok := obj.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(fieldPath) == 1 {
+		if namespace, ok := fields[fieldPath[0]].(string); ok {
+			if target, ok := mapping[namespace]; ok {
+				fields[fieldPath[0]] = target
+			}
+		}
+		return
+	}
+	remapNamespaceField(fields[fieldPath[0]], fieldPath[1:], mapping)
+}
@@ -0,0 +1,237 @@
+package restore
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	namespacesGVR          = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	configMapsGVR          = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	roleBindingsGVR        = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}
+	clusterRoleBindingsGVR = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}
+)
+
+func bindingTo(kind, namespace, name, subjectNamespace string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": kind,
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "default", "namespace": subjectNamespace},
+		},
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func subjectNamespace(obj unstructured.Unstructured) string {
+	subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+	return subjects[0].(map[string]interface{})["namespace"].(string)
+}
+
+func TestValidateNamespaceMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "distinct targets",
+			mapping: map[string]string{"team-a": "team-a-staging", "team-b": "team-b-staging"},
+		},
+		{
+			name:    "a target may be the source of another mapping",
+			mapping: map[string]string{"team-a": "team-b", "team-b": "team-c"},
+		},
+		{
+			name:    "invalid source",
+			mapping: map[string]string{"Team-A": "team-a-staging"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid target",
+			mapping: map[string]string{"team-a": "team_a"},
+			wantErr: true,
+		},
+		{
+			name:    "two sources mapped to one target",
+			mapping: map[string]string{"team-a": "staging", "team-b": "staging"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateNamespaceMapping(tt.mapping); (err != nil) != tt.wantErr {
+				t.Errorf("validateNamespaceMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemapNamespaces(t *testing.T) {
+	namespace := objInfo{Name: "team-a", GVR: namespacesGVR, ConfigPath: "namespaces#v1/team-a.json"}
+	otherNamespace := objInfo{Name: "team-b", GVR: namespacesGVR, ConfigPath: "namespaces#v1/team-b.json"}
+	configMap := objInfo{Name: "config", Namespace: "team-a", GVR: configMapsGVR, ConfigPath: "configmaps#v1/team-a/config.json"}
+	movedBinding := objInfo{Name: "binding", Namespace: "team-a", GVR: roleBindingsGVR,
+		ConfigPath: "rolebindings.rbac.authorization.k8s.io#v1/team-a/binding.json"}
+	otherBinding := objInfo{Name: "binding", Namespace: "team-b", GVR: roleBindingsGVR,
+		ConfigPath: "rolebindings.rbac.authorization.k8s.io#v1/team-b/binding.json"}
+	clusterBinding := objInfo{Name: "binding", GVR: clusterRoleBindingsGVR,
+		ConfigPath: "clusterrolebindings.rbac.authorization.k8s.io#v1/binding.json"}
+
+	cr := newObjectsFromBackupCR()
+	namespaceData := unstructured.Unstructured{Object: map[string]interface{}{"kind": "Namespace"}}
+	namespaceData.SetName("team-a")
+	namespaceData.SetLabels(map[string]string{namespaceNameLabel: "team-a"})
+	otherNamespaceData := unstructured.Unstructured{Object: map[string]interface{}{"kind": "Namespace"}}
+	otherNamespaceData.SetName("team-b")
+	configMapData := unstructured.Unstructured{Object: map[string]interface{}{"kind": "ConfigMap"}}
+	configMapData.SetNamespace("team-a")
+	configMapData.SetName("config")
+	cr.clusterscopedResourceInfoToData[namespace] = namespaceData
+	cr.clusterscopedResourceInfoToData[otherNamespace] = otherNamespaceData
+	cr.clusterscopedResourceInfoToData[clusterBinding] = bindingTo("ClusterRoleBinding", "", "binding", "team-a")
+	cr.namespacedResourceInfoToData[configMap] = configMapData
+	cr.namespacedResourceInfoToData[movedBinding] = bindingTo("RoleBinding", "team-a", "binding", "team-a")
+	cr.namespacedResourceInfoToData[otherBinding] = bindingTo("RoleBinding", "team-b", "binding", "team-a")
+	for _, info := range []objInfo{namespace, otherNamespace, clusterBinding, configMap, movedBinding, otherBinding} {
+		cr.resourcesFromBackup[info.ConfigPath] = true
+	}
+	cr.backupResourceSet.ControllerReferences = []v1.ControllerReference{{Namespace: "team-a", Name: "controller"}}
+
+	restore := &v1.Restore{Spec: v1.RestoreSpec{NamespaceMapping: map[string]string{"team-a": "team-a-staging"}}}
+	remapNamespaces(restore, &cr)
+
+	movedNamespace := objInfo{Name: "team-a-staging", GVR: namespacesGVR, ConfigPath: "namespaces#v1/team-a-staging.json"}
+	movedConfigMap := objInfo{Name: "config", Namespace: "team-a-staging", GVR: configMapsGVR,
+		ConfigPath: "configmaps#v1/team-a-staging/config.json"}
+	movedBinding.Namespace = "team-a-staging"
+	movedBinding.ConfigPath = "rolebindings.rbac.authorization.k8s.io#v1/team-a-staging/binding.json"
+
+	var clusterscoped, namespaced []objInfo
+	for info := range cr.clusterscopedResourceInfoToData {
+		clusterscoped = append(clusterscoped, info)
+	}
+	for info := range cr.namespacedResourceInfoToData {
+		namespaced = append(namespaced, info)
+	}
+	if len(clusterscoped) != 3 || len(namespaced) != 3 {
+		t.Fatalf("remapNamespaces() left %v cluster-scoped and %v namespaced objects, want 3 and 3", len(clusterscoped), len(namespaced))
+	}
+	wantResourcesFromBackup := map[string]bool{
+		movedNamespace.ConfigPath: true,
+		otherNamespace.ConfigPath: true,
+		clusterBinding.ConfigPath: true,
+		movedConfigMap.ConfigPath: true,
+		movedBinding.ConfigPath:   true,
+		otherBinding.ConfigPath:   true,
+	}
+	if !reflect.DeepEqual(cr.resourcesFromBackup, wantResourcesFromBackup) {
+		t.Errorf("resourcesFromBackup = %v, want %v", cr.resourcesFromBackup, wantResourcesFromBackup)
+	}
+
+	renamed, ok := cr.clusterscopedResourceInfoToData[movedNamespace]
+	if !ok {
+		t.Fatalf("Namespace team-a was not renamed to team-a-staging")
+	}
+	if renamed.GetName() != "team-a-staging" || renamed.GetLabels()[namespaceNameLabel] != "team-a-staging" {
+		t.Errorf("renamed Namespace has name %v and label %v, want team-a-staging", renamed.GetName(), renamed.GetLabels()[namespaceNameLabel])
+	}
+	if moved := cr.namespacedResourceInfoToData[movedConfigMap]; moved.GetNamespace() != "team-a-staging" {
+		t.Errorf("moved ConfigMap is in namespace %v, want team-a-staging", moved.GetNamespace())
+	}
+
+	tests := []struct {
+		name string
+		obj  unstructured.Unstructured
+		want string
+	}{
+		{
+			name: "references of moved objects are remapped",
+			obj:  cr.namespacedResourceInfoToData[movedBinding],
+			want: "team-a-staging",
+		},
+		{
+			name: "references of objects that aren't moved are left alone",
+			obj:  cr.namespacedResourceInfoToData[otherBinding],
+			want: "team-a",
+		},
+		{
+			name: "references of cluster-scoped objects are left alone",
+			obj:  cr.clusterscopedResourceInfoToData[clusterBinding],
+			want: "team-a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subjectNamespace(tt.obj); got != tt.want {
+				t.Errorf("subject namespace = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := cr.backupResourceSet.ControllerReferences[0].Namespace; got != "team-a-staging" {
+		t.Errorf("controller reference namespace = %v, want team-a-staging", got)
+	}
+}
+
+func TestRemapResourceSelectors(t *testing.T) {
+	mapping := map[string]string{"team-a": "team-a-staging", "team-b": "team-b-staging"}
+	tests := []struct {
+		name     string
+		selector v1.ResourceSelector
+		want     v1.ResourceSelector
+	}{
+		{
+			name:     "mapped namespaces are replaced by their targets",
+			selector: v1.ResourceSelector{APIVersion: "v1", Namespaces: []string{"team-a", "team-c"}},
+			want:     v1.ResourceSelector{APIVersion: "v1", Namespaces: []string{"team-a-staging", "team-c"}},
+		},
+		{
+			name:     "targets of mapped namespaces matching the regexp are added in order",
+			selector: v1.ResourceSelector{APIVersion: "v1", NamespaceRegexp: "^team-"},
+			want:     v1.ResourceSelector{APIVersion: "v1", NamespaceRegexp: "^team-", Namespaces: []string{"team-a-staging", "team-b-staging"}},
+		},
+		{
+			name:     "invalid regexps are left to fail gathering",
+			selector: v1.ResourceSelector{APIVersion: "v1", NamespaceRegexp: "("},
+			want:     v1.ResourceSelector{APIVersion: "v1", NamespaceRegexp: "("},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selectors := []v1.ResourceSelector{tt.selector}
+			got := remapResourceSelectors(selectors, mapping)
+			if !reflect.DeepEqual(got, []v1.ResourceSelector{tt.want}) {
+				t.Errorf("remapResourceSelectors() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(selectors[0], tt.selector) {
+				t.Errorf("remapResourceSelectors() changed its argument to %v", selectors[0])
+			}
+		})
+	}
+}
+
+func TestMappedAway(t *testing.T) {
+	mapping := map[string]string{"team-a": "team-b", "team-b": "team-c"}
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{namespace: "team-a", want: true},
+		{namespace: "team-b", want: false},
+		{namespace: "team-c", want: false},
+		{namespace: "team-d", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			if got := mappedAway(mapping, tt.namespace); got != tt.want {
+				t.Errorf("mappedAway(%v) = %v, want %v", tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
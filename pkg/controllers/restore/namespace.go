@@ -0,0 +1,33 @@
+package restore
+
+import (
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// restrictToNamespace drops everything but the namespaced resources of the Restore's restrictToNamespace from the objects read
+// from the backup file, along with the controllers to scale down outside of it, so a backup file containing other namespaces or
+// cluster-scoped resources can't be used to change them
+func restrictToNamespace(restore *v1.Restore, cr *ObjectsFromBackupCR) {
+	namespace := restore.Spec.RestrictToNamespace
+	skipped := len(cr.crdInfoToData) + len(cr.clusterscopedResourceInfoToData)
+	cr.crdInfoToData = make(map[objInfo]unstructured.Unstructured)
+	cr.clusterscopedResourceInfoToData = make(map[objInfo]unstructured.Unstructured)
+	for info := range cr.namespacedResourceInfoToData {
+		if info.Namespace != namespace {
+			delete(cr.namespacedResourceInfoToData, info)
+			skipped++
+		}
+	}
+	var controllerRefs []v1.ControllerReference
+	for _, controllerRef := range cr.backupResourceSet.ControllerReferences {
+		if controllerRef.Namespace == namespace {
+			controllerRefs = append(controllerRefs, controllerRef)
+		}
+	}
+	cr.backupResourceSet.ControllerReferences = controllerRefs
+	if skipped > 0 {
+		logrus.Infof("Skipping %v resources outside of namespace %v for restore CR %v", skipped, namespace, restore.Name)
+	}
+}
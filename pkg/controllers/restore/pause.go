@@ -3,13 +3,18 @@ package restore
 import (
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
 
-func (h *handler) scaleDownControllersFromResourceSet(objFromBackupCR ObjectsFromBackupCR) {
+func (h *handler) scaleDownControllersFromResourceSet(restore *v1.Restore, objFromBackupCR ObjectsFromBackupCR) {
+	if h.plan != nil {
+		// a dry run doesn't touch the controllers
+		return
+	}
 	for ind, controllerRef := range objFromBackupCR.backupResourceSet.ControllerReferences {
 		controllerObj, dr := h.getObjFromControllerRef(controllerRef)
 		if controllerObj == nil {
@@ -38,11 +43,18 @@ func (h *handler) scaleDownControllersFromResourceSet(objFromBackupCR ObjectsFro
 		_, err := dr.Update(h.ctx, controllerObj, k8sv1.UpdateOptions{})
 		if err != nil {
 			logrus.Errorf("Error scaling down %v/%v/%v, skipping it", controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name)
+			continue
 		}
+		h.recorder.Eventf(restore, corev1.EventTypeNormal, eventReasonScaledDown, "Scaled down controllerRef %v/%v/%v from %v replicas",
+			controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, controllerRef.Replicas)
 	}
 }
 
-func (h *handler) scaleUpControllersFromResourceSet(objFromBackupCR ObjectsFromBackupCR) {
+func (h *handler) scaleUpControllersFromResourceSet(restore *v1.Restore, objFromBackupCR ObjectsFromBackupCR) {
+	if h.plan != nil {
+		// a dry run doesn't touch the controllers
+		return
+	}
 	for _, controllerRef := range objFromBackupCR.backupResourceSet.ControllerReferences {
 		controllerObj, dr := h.getObjFromControllerRef(controllerRef)
 		if controllerObj == nil {
@@ -54,7 +66,12 @@ func (h *handler) scaleUpControllersFromResourceSet(objFromBackupCR ObjectsFromB
 		_, err := dr.Update(h.ctx, controllerObj, k8sv1.UpdateOptions{})
 		if err != nil {
 			logrus.Errorf("Error scaling up %v/%v/%v, edit it to scale back to %v", controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, controllerRef.Replicas)
+			h.recorder.Eventf(restore, corev1.EventTypeWarning, eventReasonScaledUp, "Error scaling up controllerRef %v/%v/%v, edit it to scale back to %v",
+				controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, controllerRef.Replicas)
+			continue
 		}
+		h.recorder.Eventf(restore, corev1.EventTypeNormal, eventReasonScaledUp, "Scaled up controllerRef %v/%v/%v to %v replicas",
+			controllerRef.APIVersion, controllerRef.Resource, controllerRef.Name, controllerRef.Replicas)
 	}
 }
 
@@ -0,0 +1,334 @@
+package restore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/util"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/genericcondition"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// Actions of the entries in the plan of a dry-run Restore
+const (
+	planActionCreate    = "Create"
+	planActionUpdate    = "Update"
+	planActionUnchanged = "Unchanged"
+	planActionPrune     = "Prune"
+	planActionSkip      = "Skip"
+	planActionFail      = "Fail"
+)
+
+const (
+	planConfigMapKey = "plan.json"
+	// maxConfigMapDataSize keeps the plan and the report below the 1MiB size limit of ConfigMaps, with room for the rest of the object
+	maxConfigMapDataSize = 1000 * 1000
+)
+
+type planEntry struct {
+	Action    string `json:"action"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Fields are the paths of the fields set in the backup that differ from the live object
+	Fields []string `json:"fields,omitempty"`
+	// Transformations are the names of the transformation rules applied to the object
+	Transformations []string `json:"transformations,omitempty"`
+	// Conflict is the decision for an existing object that wouldn't be overwritten, overwritten objects are planned as updates
+	Conflict *conflict `json:"conflict,omitempty"`
+}
+
+// restorePlan collects what a dry-run Restore would do, in the order the restore would do it
+type restorePlan struct {
+	mu        sync.Mutex
+	Summary   v1.RestorePlanSummary `json:"summary"`
+	Entries   []planEntry           `json:"entries"`
+	Truncated bool                  `json:"truncated,omitempty"`
+}
+
+func (p *restorePlan) add(entry planEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch entry.Action {
+	case planActionCreate:
+		p.Summary.Create++
+	case planActionUpdate:
+		p.Summary.Update++
+	case planActionUnchanged:
+		p.Summary.Unchanged++
+	case planActionPrune:
+		p.Summary.Prune++
+	case planActionSkip:
+		p.Summary.Skip++
+	case planActionFail:
+		p.Summary.Fail++
+	}
+	p.Entries = append(p.Entries, entry)
+}
+
+// planning returns a copy of the handler that records what restoring each object would change in the plan, without writing anything
+func (h *handler) planning() *handler {
+	planning := *h
+	planning.plan = &restorePlan{}
+	return &planning
+}
+
+// planResource compares the object from the backup with the live object, the way restoreResource would create or update it
+func (h *handler) planResource(restoreObjInfo objInfo, restoreObjData unstructured.Unstructured, hasStatusSubresource bool,
+	transformations []string) error {
+	var dr dynamic.ResourceInterface
+	dr = h.dynamicClient.Resource(restoreObjInfo.GVR)
+	if restoreObjInfo.Namespace != "" {
+		dr = h.dynamicClient.Resource(restoreObjInfo.GVR).Namespace(restoreObjInfo.Namespace)
+	}
+	entry := planEntry{
+		Resource:  restoreObjInfo.GVR.String(),
+		Namespace: restoreObjInfo.Namespace,
+		Name:      restoreObjInfo.Name,
+		// transformed objects are compared as they would be restored
+		Transformations: transformations,
+	}
+	live, err := dr.Get(h.ctx, restoreObjInfo.Name, k8sv1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("planResource: err getting resource %w", err)
+		}
+		// this includes objects of CRDs that would be restored first, so they aren't served yet
+		entry.Action = planActionCreate
+		h.plan.add(entry)
+		return nil
+	}
+	if objConflict := h.conflicts.resolve(restoreObjInfo.GVR, live); objConflict.Decision != conflictDecisionOverwritten {
+		entry.Action = planActionSkip
+		if objConflict.Decision == conflictDecisionFailed {
+			entry.Action = planActionFail
+		}
+		entry.Conflict = &objConflict
+		h.plan.add(entry)
+		return nil
+	}
+	entry.Fields = changedFields(restoreObjData.Object, live.Object, hasStatusSubresource)
+	entry.Action = planActionUnchanged
+	if len(entry.Fields) > 0 {
+		entry.Action = planActionUpdate
+	}
+	h.plan.add(entry)
+	return nil
+}
+
+// changedFields returns the paths of the fields of the object from the backup whose value differs from the live object. Fields only
+// the live object has are ignored, since they are mostly defaulted by the apiserver. Of the metadata only the labels, annotations and
+// finalizers are compared, the rest is managed by the apiserver or rewritten by the restore
+func changedFields(backupObj, liveObj map[string]interface{}, hasStatusSubresource bool) []string {
+	var fields []string
+	for key, value := range backupObj {
+		switch key {
+		case metadataMapKey:
+			backupMetadata, _ := value.(map[string]interface{})
+			liveMetadata, _ := liveObj[metadataMapKey].(map[string]interface{})
+			for _, metadataKey := range []string{"labels", "annotations", "finalizers"} {
+				diffValues("metadata."+metadataKey, backupMetadata[metadataKey], liveMetadata[metadataKey], &fields)
+			}
+		case "status":
+			if hasStatusSubresource {
+				diffValues(key, value, liveObj[key], &fields)
+			}
+		default:
+			diffValues(key, value, liveObj[key], &fields)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func diffValues(path string, backupValue, liveValue interface{}, fields *[]string) {
+	backupMap, backupIsMap := backupValue.(map[string]interface{})
+	liveMap, liveIsMap := liveValue.(map[string]interface{})
+	if backupIsMap && liveIsMap {
+		for key, value := range backupMap {
+			diffValues(path+"."+key, value, liveMap[key], fields)
+		}
+		return
+	}
+	if backupValue == nil {
+		return
+	}
+	if !equalValues(backupValue, liveValue) {
+		*fields = append(*fields, path)
+	}
+}
+
+// equalValues compares values decoded from JSON, numbers from the backup file are float64 while the dynamic client returns int64
+func equalValues(a, b interface{}) bool {
+	if aNumber, ok := toFloat(a); ok {
+		bNumber, ok := toFloat(b)
+		return ok && aNumber == bNumber
+	}
+	switch aValue := a.(type) {
+	case []interface{}:
+		bValue, ok := b.([]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			return false
+		}
+		for i := range aValue {
+			if !equalValues(aValue[i], bValue[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bValue, ok := b.(map[string]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			return false
+		}
+		for key := range aValue {
+			if !equalValues(aValue[key], bValue[key]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+// planPrune adds the resources the restore would prune to the plan
+func (h *handler) planPrune(resourcesToDelete []pruneResourceInfo) {
+	for _, resource := range resourcesToDelete {
+		h.plan.add(planEntry{
+			Action:    planActionPrune,
+			Resource:  resource.gvr.String(),
+			Namespace: resource.namespace,
+			Name:      resource.name,
+		})
+	}
+}
+
+// savePlan stores the plan of the dry-run Restore in its ConfigMap, truncated to fit. Returns the namespace/name of the ConfigMap
+func (h *handler) savePlan(restore *v1.Restore) (string, error) {
+	data, err := json.Marshal(h.plan)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxConfigMapDataSize {
+		// keep the summary and the changes, and drop the objects that wouldn't change, then anything past the size limit
+		logrus.Warnf("Plan of restore CR %v is too large for a ConfigMap, truncating it", restore.Name)
+		h.plan.Truncated = true
+		var entries []planEntry
+		for _, entry := range h.plan.Entries {
+			if entry.Action != planActionUnchanged {
+				entries = append(entries, entry)
+			}
+		}
+		for {
+			h.plan.Entries = entries
+			if data, err = json.Marshal(h.plan); err != nil {
+				return "", err
+			}
+			if len(data) <= maxConfigMapDataSize || len(entries) == 0 {
+				break
+			}
+			entries = entries[:len(entries)*9/10]
+		}
+	}
+	return h.saveConfigMap(restore, restore.Name+"-dry-run", planConfigMapKey, data)
+}
+
+// saveConfigMap stores data of the Restore under the key of a ConfigMap owned by it, in the namespace the Restore is restricted to or
+// else the operator's namespace. Returns the namespace/name of the ConfigMap
+func (h *handler) saveConfigMap(restore *v1.Restore, name, key string, data []byte) (string, error) {
+	namespace := restore.Spec.RestrictToNamespace
+	if namespace == "" {
+		namespace = util.ChartNamespace
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: k8sv1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			OwnerReferences: []k8sv1.OwnerReference{
+				*k8sv1.NewControllerRef(restore, v1.SchemeGroupVersion.WithKind("Restore")),
+			},
+		},
+		Data: map[string]string{
+			key: string(data),
+		},
+	}
+	existing, err := h.configMaps.Get(namespace, name, k8sv1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		_, err = h.configMaps.Create(configMap)
+	} else {
+		existing.Data = configMap.Data
+		_, err = h.configMaps.Update(existing)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error saving ConfigMap %v/%v: %v", namespace, name, err)
+	}
+	return namespace + "/" + name, nil
+}
+
+// completeDryRun saves the plan and completes the Restore with its summary, so it isn't planned again
+func (h *handler) completeDryRun(restore *v1.Restore, backupSource string) (*v1.Restore, error) {
+	configMapName, err := h.savePlan(restore)
+	if err != nil {
+		return h.setReconcilingCondition(restore, err)
+	}
+	summary := h.plan.Summary
+	summary.ConfigMap = configMapName
+	message := fmt.Sprintf("Dry run completed: %v to create, %v to update, %v unchanged, %v to prune, see ConfigMap %v",
+		summary.Create, summary.Update, summary.Unchanged, summary.Prune, configMapName)
+	if summary.Skip > 0 || summary.Fail > 0 {
+		message = fmt.Sprintf("%v. By the conflict policies %v existing objects would be skipped and %v would fail the restore",
+			message, summary.Skip, summary.Fail)
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updRestore, err := h.restores.Get(restore.Name, k8sv1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		// reset conditions to remove the reconciling condition, because as per kstatus lib its presence is considered an error
+		updRestore.Status.Conditions = []genericcondition.GenericCondition{}
+		condition.Cond(v1.RestoreConditionReady).SetStatusBool(updRestore, true)
+		condition.Cond(v1.RestoreConditionReady).Message(updRestore, message)
+		updRestore.Status.RestoreCompletionTS = time.Now().Format(time.RFC3339)
+		updRestore.Status.ObservedGeneration = updRestore.Generation
+		updRestore.Status.BackupSource = backupSource
+		updRestore.Status.DryRunPlan = &summary
+		updRestore, err = h.restores.UpdateStatus(updRestore)
+		if err == nil {
+			restore = updRestore
+		}
+		return err
+	})
+	if err != nil {
+		return h.setReconcilingCondition(restore, err)
+	}
+	h.recorder.Event(restore, corev1.EventTypeNormal, eventReasonDryRun, message)
+	logrus.Infof("Done planning restore CR %v: %v", restore.Name, message)
+	return restore, nil
+}
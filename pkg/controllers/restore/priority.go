@@ -0,0 +1,115 @@
+package restore
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ParseResourcePriority parses a priority list of resources in the resource.group format of kubectl, such as "namespaces" or
+// "validatingwebhookconfigurations.admissionregistration.k8s.io"
+func ParseResourcePriority(priority []string) ([]schema.GroupResource, error) {
+	var groupResources []schema.GroupResource
+	listed := make(map[schema.GroupResource]bool)
+	for _, resource := range priority {
+		resource = strings.TrimSpace(resource)
+		if resource == "" || strings.ContainsAny(resource, "/ ") {
+			return nil, fmt.Errorf("invalid resource %q in resourcePriority, must be a resource or resource.group", resource)
+		}
+		gr := schema.ParseGroupResource(strings.ToLower(resource))
+		if listed[gr] {
+			return nil, fmt.Errorf("resourcePriority lists %v more than once", gr)
+		}
+		listed[gr] = true
+		groupResources = append(groupResources, gr)
+	}
+	return groupResources, nil
+}
+
+// namespacesResource is restored in the first wave whatever the priority list says, the namespaced objects of every wave need it
+var namespacesResource = schema.GroupResource{Resource: "namespaces"}
+
+// ownerKey identifies an object of the backup file by the fields of the ownerReferences pointing to it
+type ownerKey struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// restoreWaves splits the cluster-scoped and namespaced objects to restore into waves: one for the namespaces, then one for each
+// other resource of the priority list in its order, then one for the resources it doesn't list. Dependents are moved to the wave of
+// their last owner in the backup file, so that the ownerRef graph of each wave only waits for owners restored in it or before it.
+// Empty waves are left out
+func restoreWaves(priority []schema.GroupResource, objFromBackupCR ObjectsFromBackupCR) []ObjectsFromBackupCR {
+	if len(priority) == 0 {
+		return []ObjectsFromBackupCR{objFromBackupCR}
+	}
+	rank := map[schema.GroupResource]int{namespacesResource: 0}
+	for _, gr := range priority {
+		if _, ok := rank[gr]; !ok {
+			rank[gr] = len(rank)
+		}
+	}
+	scopes := []map[objInfo]unstructured.Unstructured{
+		objFromBackupCR.clusterscopedResourceInfoToData,
+		objFromBackupCR.namespacedResourceInfoToData,
+	}
+	waveOf := make(map[objInfo]int)
+	owners := make(map[ownerKey]objInfo)
+	for _, resourceInfoToData := range scopes {
+		for resourceInfo, resourceData := range resourceInfoToData {
+			wave, ok := rank[resourceInfo.GVR.GroupResource()]
+			if !ok {
+				wave = len(rank)
+			}
+			waveOf[resourceInfo] = wave
+			owners[ownerKey{resourceInfo.GVR.Group, resourceData.GetKind(), resourceInfo.Namespace, resourceInfo.Name}] = resourceInfo
+		}
+	}
+	// waves only ever move later, so this stops once the owners moved by a pass have taken their dependents along
+	for moved := true; moved; {
+		moved = false
+		for _, resourceInfoToData := range scopes {
+			for resourceInfo, resourceData := range resourceInfoToData {
+				for _, ownerRef := range resourceData.GetOwnerReferences() {
+					gv, err := schema.ParseGroupVersion(ownerRef.APIVersion)
+					if err != nil {
+						continue
+					}
+					// namespaced owners are in the namespace of their dependents, cluster-scoped owners in none
+					owner, ok := owners[ownerKey{gv.Group, ownerRef.Kind, resourceInfo.Namespace, ownerRef.Name}]
+					if !ok {
+						owner, ok = owners[ownerKey{gv.Group, ownerRef.Kind, "", ownerRef.Name}]
+					}
+					if ok && waveOf[owner] > waveOf[resourceInfo] {
+						waveOf[resourceInfo] = waveOf[owner]
+						moved = true
+					}
+				}
+			}
+		}
+	}
+
+	waves := make([]ObjectsFromBackupCR, len(rank)+1)
+	for i := range waves {
+		waves[i] = objFromBackupCR
+		waves[i].clusterscopedResourceInfoToData = make(map[objInfo]unstructured.Unstructured)
+		waves[i].namespacedResourceInfoToData = make(map[objInfo]unstructured.Unstructured)
+	}
+	for resourceInfo, resourceData := range objFromBackupCR.clusterscopedResourceInfoToData {
+		waves[waveOf[resourceInfo]].clusterscopedResourceInfoToData[resourceInfo] = resourceData
+	}
+	for resourceInfo, resourceData := range objFromBackupCR.namespacedResourceInfoToData {
+		waves[waveOf[resourceInfo]].namespacedResourceInfoToData[resourceInfo] = resourceData
+	}
+	var nonEmpty []ObjectsFromBackupCR
+	for _, wave := range waves {
+		if len(wave.clusterscopedResourceInfoToData) > 0 || len(wave.namespacedResourceInfoToData) > 0 {
+			nonEmpty = append(nonEmpty, wave)
+		}
+	}
+	return nonEmpty
+}
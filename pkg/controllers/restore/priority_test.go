@@ -0,0 +1,188 @@
+package restore
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var replicaSetsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+
+// waveObject is an object of the backup file for restoreWaves, owned by the objects of owners
+type waveObject struct {
+	gvr       schema.GroupVersionResource
+	kind      string
+	namespace string
+	name      string
+	owners    []k8sv1.OwnerReference
+}
+
+func (o waveObject) String() string {
+	return o.gvr.Resource + "/" + o.namespace + "/" + o.name
+}
+
+func ownedBy(apiVersion, kind, name string) []k8sv1.OwnerReference {
+	return []k8sv1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name}}
+}
+
+func TestParseResourcePriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority []string
+		want     []schema.GroupResource
+		wantErr  bool
+	}{
+		{
+			name: "no priority",
+		},
+		{
+			name:     "resources and groups",
+			priority: []string{"namespaces", " CustomResourceDefinitions.apiextensions.k8s.io ", "deployments.apps"},
+			want: []schema.GroupResource{
+				{Resource: "namespaces"},
+				{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+				{Group: "apps", Resource: "deployments"},
+			},
+		},
+		{
+			name:     "empty resource",
+			priority: []string{"namespaces", " "},
+			wantErr:  true,
+		},
+		{
+			name:     "apiVersion instead of group",
+			priority: []string{"apps/v1/deployments"},
+			wantErr:  true,
+		},
+		{
+			name:     "resource listed twice",
+			priority: []string{"deployments.apps", "Deployments.apps"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResourcePriority(tt.priority)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResourcePriority() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseResourcePriority() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreWaves(t *testing.T) {
+	namespace := waveObject{gvr: namespacesGVR, kind: "Namespace", name: "app"}
+	clusterRoleBinding := waveObject{gvr: clusterRoleBindingsGVR, kind: "ClusterRoleBinding", name: "admin"}
+	configMap := waveObject{gvr: configMapsGVR, kind: "ConfigMap", namespace: "app", name: "config"}
+	deployment := waveObject{gvr: deploymentsGVR, kind: "Deployment", namespace: "app", name: "web"}
+	replicaSet := waveObject{gvr: replicaSetsGVR, kind: "ReplicaSet", namespace: "app", name: "web-5d4f",
+		owners: ownedBy("apps/v1", "Deployment", "web")}
+	ownedConfigMap := waveObject{gvr: configMapsGVR, kind: "ConfigMap", namespace: "app", name: "web-5d4f-config",
+		owners: ownedBy("apps/v1", "ReplicaSet", "web-5d4f")}
+	bindingConfigMap := waveObject{gvr: configMapsGVR, kind: "ConfigMap", namespace: "app", name: "admin-config",
+		owners: ownedBy("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", "admin")}
+	otherNamespaceConfigMap := waveObject{gvr: configMapsGVR, kind: "ConfigMap", namespace: "other", name: "web-config",
+		owners: ownedBy("apps/v1", "Deployment", "web")}
+
+	tests := []struct {
+		name     string
+		priority []string
+		objects  []waveObject
+		want     [][]waveObject
+	}{
+		{
+			name:    "no priority is one wave",
+			objects: []waveObject{namespace, configMap, deployment},
+			want:    [][]waveObject{{namespace, configMap, deployment}},
+		},
+		{
+			name:     "resources in the order of the priority, unlisted ones last",
+			priority: []string{"deployments.apps", "configmaps"},
+			objects:  []waveObject{namespace, clusterRoleBinding, configMap, deployment},
+			want:     [][]waveObject{{namespace}, {deployment}, {configMap}, {clusterRoleBinding}},
+		},
+		{
+			name:     "namespaces first even when listed later",
+			priority: []string{"configmaps", "namespaces"},
+			objects:  []waveObject{namespace, configMap},
+			want:     [][]waveObject{{namespace}, {configMap}},
+		},
+		{
+			name:     "empty waves are left out",
+			priority: []string{"secrets", "deployments.apps", "services"},
+			objects:  []waveObject{configMap, deployment},
+			want:     [][]waveObject{{deployment}, {configMap}},
+		},
+		{
+			name:     "dependents move to the wave of their owners",
+			priority: []string{"configmaps", "replicasets.apps", "deployments.apps"},
+			objects:  []waveObject{configMap, deployment, replicaSet, ownedConfigMap},
+			want:     [][]waveObject{{configMap}, {deployment, replicaSet, ownedConfigMap}},
+		},
+		{
+			name:     "dependents of cluster-scoped owners",
+			priority: []string{"configmaps"},
+			objects:  []waveObject{clusterRoleBinding, bindingConfigMap},
+			want:     [][]waveObject{{clusterRoleBinding, bindingConfigMap}},
+		},
+		{
+			name:     "owners in other namespaces are no owners",
+			priority: []string{"configmaps", "deployments.apps"},
+			objects:  []waveObject{deployment, otherNamespaceConfigMap},
+			want:     [][]waveObject{{otherNamespaceConfigMap}, {deployment}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			priority, err := ParseResourcePriority(tt.priority)
+			if err != nil {
+				t.Fatalf("ParseResourcePriority() error = %v", err)
+			}
+			objFromBackupCR := newObjectsFromBackupCR()
+			for _, o := range tt.objects {
+				obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+				obj.SetKind(o.kind)
+				obj.SetNamespace(o.namespace)
+				obj.SetName(o.name)
+				obj.SetOwnerReferences(o.owners)
+				info := objInfo{Name: o.name, Namespace: o.namespace, GVR: o.gvr}
+				if o.namespace == "" {
+					objFromBackupCR.clusterscopedResourceInfoToData[info] = obj
+				} else {
+					objFromBackupCR.namespacedResourceInfoToData[info] = obj
+				}
+			}
+
+			var got, want [][]string
+			for _, wave := range restoreWaves(priority, objFromBackupCR) {
+				var names []string
+				for info := range wave.clusterscopedResourceInfoToData {
+					names = append(names, info.GVR.Resource+"/"+info.Namespace+"/"+info.Name)
+				}
+				for info := range wave.namespacedResourceInfoToData {
+					names = append(names, info.GVR.Resource+"/"+info.Namespace+"/"+info.Name)
+				}
+				sort.Strings(names)
+				got = append(got, names)
+			}
+			for _, wave := range tt.want {
+				var names []string
+				for _, o := range wave {
+					names = append(names, o.String())
+				}
+				sort.Strings(names)
+				want = append(want, names)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("restoreWaves() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"time"
 
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
 	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
 	"github.com/rancher/backup-restore-operator/pkg/util"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -24,13 +26,18 @@ type pruneResourceInfo struct {
 	gvr       schema.GroupVersionResource
 }
 
-func (h *handler) prune(resourceSelectors []v1.ResourceSelector, transformerMap map[schema.GroupResource]value.Transformer,
+func (h *handler) prune(restore *v1.Restore, resourceSelectors []v1.ResourceSelector, transformerMap map[schema.GroupResource]value.Transformer,
 	cr ObjectsFromBackupCR, deleteTimeout int) error {
 	var resourcesToDelete []pruneResourceInfo
+	mapping := restore.Spec.NamespaceMapping
+	if len(mapping) > 0 {
+		resourceSelectors = remapResourceSelectors(resourceSelectors, mapping)
+	}
 	rh := resourcesets.ResourceHandler{
 		DiscoveryClient: h.discoveryClient,
 		DynamicClient:   h.dynamicClient,
 		TransformerMap:  transformerMap,
+		Namespace:       restore.Spec.RestrictToNamespace,
 	}
 
 	if _, err := rh.GatherResources(h.ctx, resourceSelectors); err != nil {
@@ -49,6 +56,17 @@ func (h *handler) prune(resourceSelectors []v1.ResourceSelector, transformerMap
 			}
 			resourceFilePath := filepath.Join(resourcePath, objName+".json")
 			logrus.Infof("resourceFilePath: %v", resourceFilePath)
+			// a selective restore only prunes within the resources it selects
+			selected, err := restoreSelects(restore, gv.WithResource(gvResource.Name), &resObj)
+			if err != nil {
+				return err
+			}
+			if !selected {
+				continue
+			}
+			if mappedAway(mapping, objNs) || (gvResource.Name == "namespaces" && gv.Group == "" && mappedAway(mapping, objName)) {
+				continue
+			}
 			if !cr.resourcesFromBackup[resourceFilePath] {
 				logrus.Infof("Marking resource %v for deletion", strings.TrimSuffix(resourceFilePath, ".json"))
 				resourcesToDelete = append(resourcesToDelete, pruneResourceInfo{
@@ -59,6 +77,14 @@ func (h *handler) prune(resourceSelectors []v1.ResourceSelector, transformerMap
 			}
 		}
 	}
+	if len(resourcesToDelete) == 0 {
+		return nil
+	}
+	if h.plan != nil {
+		h.planPrune(resourcesToDelete)
+		return nil
+	}
+	h.recorder.Eventf(restore, corev1.EventTypeNormal, eventReasonPruned, "Pruning %v resources that are not part of the backup", len(resourcesToDelete))
 	return h.pruneClusterScopedResources(resourcesToDelete, deleteTimeout)
 }
 
@@ -110,7 +136,9 @@ func (h *handler) deleteResources(resourcesToDelete []pruneResourceInfo, removeF
 						continue
 					}
 					errList = append(errList, err)
+					continue
 				}
+				metrics.ObjectPruned()
 			}
 			return util.ErrList(errList)
 		})
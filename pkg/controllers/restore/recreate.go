@@ -0,0 +1,106 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// defaultRecreateTimeout is how long to wait for the finalizers of an object being recreated if deleteTimeoutSeconds isn't set
+const defaultRecreateTimeout = 10 * time.Second
+
+// recreating returns a copy of the handler that deletes and creates again the objects of the Restore's recreateKinds when updating
+// them fails on immutable fields
+func (h *handler) recreating(restore *v1.Restore) (*handler, error) {
+	recreateKinds := make(map[schema.GroupVersionKind]bool)
+	for _, kind := range restore.Spec.RecreateKinds {
+		if kind.APIVersion == "" || kind.Kind == "" {
+			return nil, fmt.Errorf("recreateKinds must have an apiVersion and a kind")
+		}
+		gv, err := schema.ParseGroupVersion(kind.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid apiVersion %v in recreateKinds: %v", kind.APIVersion, err)
+		}
+		recreateKinds[gv.WithKind(kind.Kind)] = true
+	}
+	recreating := *h
+	recreating.recreateKinds = recreateKinds
+	recreating.recreateTimeout = defaultRecreateTimeout
+	if restore.Spec.DeleteTimeoutSeconds > 0 {
+		recreating.recreateTimeout = time.Duration(restore.Spec.DeleteTimeoutSeconds) * time.Second
+	}
+	return &recreating, nil
+}
+
+// mayRecreate returns true if restoring the object failed on immutable fields and its kind may be recreated
+func (h *handler) mayRecreate(obj unstructured.Unstructured, err error) bool {
+	return h.recreateKinds[obj.GroupVersionKind()] && isImmutableFieldError(err)
+}
+
+// isImmutableFieldError returns true for the errors the apiserver returns when an update changes immutable fields, such as the
+// clusterIP of a Service or the selector of a Job
+func isImmutableFieldError(err error) bool {
+	// restoreResource wraps the errors, which apierrors.IsInvalid doesn't unwrap
+	var statusErr apierrors.APIStatus
+	if !errors.As(err, &statusErr) || statusErr.Status().Reason != k8sv1.StatusReasonInvalid {
+		return false
+	}
+	if details := statusErr.Status().Details; details != nil {
+		for _, cause := range details.Causes {
+			if strings.Contains(cause.Message, "immutable") {
+				return true
+			}
+		}
+	}
+	return strings.Contains(statusErr.Status().Message, "immutable")
+}
+
+// recreateResource deletes the live object and restores the object from the backup in its place. The dependents of the live object
+// are orphaned rather than deleted along with it, and its finalizers are left to complete, failing the restore if they don't
+// before the timeout
+func (h *handler) recreateResource(dr dynamic.ResourceInterface, live *unstructured.Unstructured, obj unstructured.Unstructured,
+	gvr schema.GroupVersionResource, hasStatusSubresource bool) error {
+	name := live.GetName()
+	uid := live.GetUID()
+	orphan := k8sv1.DeletePropagationOrphan
+	err := dr.Delete(h.ctx, name, k8sv1.DeleteOptions{
+		// don't delete an object that was already recreated by someone else
+		Preconditions:     &k8sv1.Preconditions{UID: &uid},
+		PropagationPolicy: &orphan,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("restoreResource: err deleting resource to recreate it %w", err)
+	}
+	err = wait.PollImmediate(500*time.Millisecond, h.recreateTimeout, func() (bool, error) {
+		current, err := dr.Get(h.ctx, name, k8sv1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return current.GetUID() != uid, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("restoreResource: %v of type %v is still being deleted after %v, check its finalizers", name, gvr, h.recreateTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("restoreResource: err waiting for resource to be deleted %w", err)
+	}
+	logrus.Infof("Deleted %v of type %v, creating it again", name, gvr)
+	unstructured.RemoveNestedField(obj.Object, metadataMapKey, "resourceVersion")
+	if h.applyOptions != nil {
+		return h.applyResource(dr, obj, hasStatusSubresource)
+	}
+	return h.createResource(dr, obj, gvr, hasStatusSubresource)
+}
@@ -0,0 +1,111 @@
+package restore
+
+import (
+	"encoding/json"
+	"sync"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+)
+
+const reportConfigMapKey = "report.json"
+
+type reportEntry struct {
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Transformations are the names of the transformation rules applied to the object, in order
+	Transformations []string `json:"transformations,omitempty"`
+	// Conflict is the decision taken if the object already existed
+	Conflict *conflict `json:"conflict,omitempty"`
+	// Recreated is true if the object was deleted and created again because its immutable fields differed from the backup
+	Recreated bool `json:"recreated,omitempty"`
+}
+
+// restoreReport collects what a Restore did to the objects it restored beyond creating them as they are in the backup file
+type restoreReport struct {
+	mu        sync.Mutex
+	Summary   v1.RestoreReportSummary `json:"summary"`
+	Entries   []*reportEntry          `json:"entries"`
+	Truncated bool                    `json:"truncated,omitempty"`
+}
+
+// add records the transformation rules applied to the object and the decision taken if it already existed, and returns the entry.
+// Objects restored as they are in the backup file without conflict aren't recorded
+func (r *restoreReport) add(restoreObjInfo objInfo, transformations []string, objConflict *conflict) *reportEntry {
+	if len(transformations) == 0 && objConflict == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Summary.Transformations += len(transformations)
+	if objConflict != nil {
+		switch objConflict.Decision {
+		case conflictDecisionOverwritten:
+			r.Summary.Overwritten++
+		case conflictDecisionSkipped:
+			r.Summary.Skipped++
+		case conflictDecisionFailed:
+			r.Summary.Failed++
+		}
+	}
+	entry := &reportEntry{
+		Resource:        restoreObjInfo.GVR.String(),
+		Namespace:       restoreObjInfo.Namespace,
+		Name:            restoreObjInfo.Name,
+		Transformations: transformations,
+		Conflict:        objConflict,
+	}
+	r.Entries = append(r.Entries, entry)
+	return entry
+}
+
+// recreated records that the object of the entry was recreated
+func (r *restoreReport) recreated(entry *reportEntry) {
+	if entry == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Summary.Recreated++
+	entry.Recreated = true
+}
+
+// reporting returns a copy of the handler that records the transformations and conflicts of the objects it restores in a report
+func (h *handler) reporting() *handler {
+	reporting := *h
+	reporting.report = &restoreReport{}
+	return &reporting
+}
+
+// saveReport stores the report in a ConfigMap owned by the Restore, truncated to fit, and returns its summary. Failing to save it
+// doesn't fail the restore, the summary then names no ConfigMap
+func (h *handler) saveReport(restore *v1.Restore) *v1.RestoreReportSummary {
+	if h.report == nil {
+		return nil
+	}
+	summary := h.report.Summary
+	data, err := json.Marshal(h.report)
+	if err != nil {
+		logrus.Errorf("Error marshaling report of restore CR %v: %v", restore.Name, err)
+		return &summary
+	}
+	if len(data) > maxConfigMapDataSize {
+		logrus.Warnf("Report of restore CR %v is too large for a ConfigMap, truncating it", restore.Name)
+		h.report.Truncated = true
+		for len(data) > maxConfigMapDataSize && len(h.report.Entries) > 0 {
+			h.report.Entries = h.report.Entries[:len(h.report.Entries)*9/10]
+			if data, err = json.Marshal(h.report); err != nil {
+				logrus.Errorf("Error marshaling report of restore CR %v: %v", restore.Name, err)
+				return &summary
+			}
+		}
+	}
+	configMapName, err := h.saveConfigMap(restore, restore.Name+"-report", reportConfigMapKey, data)
+	if err != nil {
+		logrus.Errorf("Error saving report of restore CR %v: %v", restore.Name, err)
+		return &summary
+	}
+	summary.ConfigMap = configMapName
+	return &summary
+}
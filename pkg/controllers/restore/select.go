@@ -0,0 +1,91 @@
+package restore
+
+import (
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// selectResources drops the objects not selected by the Restore's include and exclude resource selectors from the objects read
+// from the backup file. Dropped objects are recorded as excluded, so that their dependents are still restored, with the owners
+// that already exist in the cluster. Controllers are only scaled down if the selection restores them
+func selectResources(restore *v1.Restore, cr *ObjectsFromBackupCR) error {
+	skipped := 0
+	for _, resourceInfoToData := range []map[objInfo]unstructured.Unstructured{
+		cr.crdInfoToData, cr.clusterscopedResourceInfoToData, cr.namespacedResourceInfoToData} {
+		for info, data := range resourceInfoToData {
+			selected, err := restoreSelects(restore, info.GVR, &data)
+			if err != nil {
+				return err
+			}
+			if !selected {
+				delete(resourceInfoToData, info)
+				cr.excludedResources[info.ConfigPath] = true
+				skipped++
+			}
+		}
+	}
+
+	var controllerRefs []v1.ControllerReference
+	for _, controllerRef := range cr.backupResourceSet.ControllerReferences {
+		gv, err := schema.ParseGroupVersion(controllerRef.APIVersion)
+		if err != nil {
+			continue
+		}
+		info := objInfo{
+			Name:      controllerRef.Name,
+			Namespace: controllerRef.Namespace,
+			GVR:       gv.WithResource(controllerRef.Resource),
+		}
+		if !cr.hasObject(info) {
+			continue
+		}
+		controllerRefs = append(controllerRefs, controllerRef)
+	}
+	cr.backupResourceSet.ControllerReferences = controllerRefs
+	if skipped > 0 {
+		logrus.Infof("Skipping %v resources not selected by restore CR %v", skipped, restore.Name)
+	}
+	return nil
+}
+
+// restoreSelects returns true if the object matches any of the Restore's include selectors, or there are none, and none of its
+// exclude selectors
+func restoreSelects(restore *v1.Restore, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (bool, error) {
+	included := len(restore.Spec.IncludeResourceSelectors) == 0
+	for _, selector := range restore.Spec.IncludeResourceSelectors {
+		matched, err := resourcesets.MatchesSelector(selector, gvr, obj)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false, nil
+	}
+	for _, selector := range restore.Spec.ExcludeResourceSelectors {
+		matched, err := resourcesets.MatchesSelector(selector, gvr, obj)
+		if err != nil || matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// hasObject returns true if the object is to be restored, ignoring the path of its file in the backup
+func (cr *ObjectsFromBackupCR) hasObject(info objInfo) bool {
+	for _, resourceInfoToData := range []map[objInfo]unstructured.Unstructured{
+		cr.crdInfoToData, cr.clusterscopedResourceInfoToData, cr.namespacedResourceInfoToData} {
+		for restoreInfo := range resourceInfoToData {
+			if restoreInfo.Name == info.Name && restoreInfo.Namespace == info.Namespace && restoreInfo.GVR == info.GVR {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package restore
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/resourcesets"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// transformation is a TransformationRule of the Restore with its patches decoded and its regexps compiled
+type transformation struct {
+	rule                v1.TransformationRule
+	jsonPatch           jsonpatch.Patch
+	strategicMergePatch []byte
+	replacements        []fieldReplacement
+}
+
+type fieldReplacement struct {
+	path   []string
+	regexp *regexp.Regexp
+	value  string
+}
+
+// compileTransformations validates the Restore's transformation rules. Patches can be written in JSON or YAML
+func compileTransformations(rules []v1.TransformationRule) ([]transformation, error) {
+	var transformations []transformation
+	names := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("transformation rules must have a name")
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate transformation rule %v", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.ResourceSelector.APIVersion == "" {
+			return nil, fmt.Errorf("transformation rule %v: resourceSelector.apiVersion must be set", rule.Name)
+		}
+		if rule.JSONPatch == "" && rule.StrategicMergePatch == "" && len(rule.FieldReplacements) == 0 {
+			return nil, fmt.Errorf("transformation rule %v: one of jsonPatch, strategicMergePatch or fieldReplacements must be set", rule.Name)
+		}
+		t := transformation{rule: rule}
+		if rule.JSONPatch != "" {
+			data, err := yaml.YAMLToJSON([]byte(rule.JSONPatch))
+			if err != nil {
+				return nil, fmt.Errorf("transformation rule %v: invalid jsonPatch: %v", rule.Name, err)
+			}
+			if t.jsonPatch, err = jsonpatch.DecodePatch(data); err != nil {
+				return nil, fmt.Errorf("transformation rule %v: invalid jsonPatch: %v", rule.Name, err)
+			}
+		}
+		if rule.StrategicMergePatch != "" {
+			data, err := yaml.YAMLToJSON([]byte(rule.StrategicMergePatch))
+			if err != nil {
+				return nil, fmt.Errorf("transformation rule %v: invalid strategicMergePatch: %v", rule.Name, err)
+			}
+			var patch map[string]interface{}
+			if err := json.Unmarshal(data, &patch); err != nil {
+				return nil, fmt.Errorf("transformation rule %v: strategicMergePatch must be an object: %v", rule.Name, err)
+			}
+			t.strategicMergePatch = data
+		}
+		for _, replacement := range rule.FieldReplacements {
+			if replacement.Path == "" {
+				return nil, fmt.Errorf("transformation rule %v: fieldReplacements must have a path", rule.Name)
+			}
+			compiled := fieldReplacement{
+				path:  strings.Split(replacement.Path, "."),
+				value: replacement.Value,
+			}
+			if replacement.Regexp != "" {
+				var err error
+				if compiled.regexp, err = regexp.Compile(replacement.Regexp); err != nil {
+					return nil, fmt.Errorf("transformation rule %v: invalid regexp for %v: %v", rule.Name, replacement.Path, err)
+				}
+			}
+			t.replacements = append(t.replacements, compiled)
+		}
+		transformations = append(transformations, t)
+	}
+	return transformations, nil
+}
+
+// transforming returns a copy of the handler that applies the Restore's transformation rules to the objects before restoring them
+func (h *handler) transforming(restore *v1.Restore) (*handler, error) {
+	transformations, err := compileTransformations(restore.Spec.Transformations)
+	if err != nil {
+		return nil, err
+	}
+	transforming := *h
+	transforming.transformations = transformations
+	return &transforming, nil
+}
+
+// transform applies the rules selecting the object in the order of the Restore's transformations, and returns the transformed
+// object with the names of the rules applied to it. The rules see the object as it would be restored, after namespaceMapping
+func (h *handler) transform(restoreObjInfo objInfo, obj unstructured.Unstructured) (unstructured.Unstructured, []string, error) {
+	var applied []string
+	for _, t := range h.transformations {
+		selected, err := resourcesets.MatchesSelector(t.rule.ResourceSelector, restoreObjInfo.GVR, &obj)
+		if err != nil {
+			return obj, nil, fmt.Errorf("transformation rule %v: %v", t.rule.Name, err)
+		}
+		if !selected {
+			continue
+		}
+		transformed, err := t.apply(obj)
+		if err != nil {
+			return obj, nil, fmt.Errorf("error applying transformation rule %v to %v %v: %v", t.rule.Name, restoreObjInfo.GVR.Resource,
+				restoreObjInfo.Name, err)
+		}
+		if transformed.GetName() != obj.GetName() || transformed.GetNamespace() != obj.GetNamespace() {
+			return obj, nil, fmt.Errorf("transformation rule %v cannot change the name or namespace of %v %v", t.rule.Name,
+				restoreObjInfo.GVR.Resource, restoreObjInfo.Name)
+		}
+		obj = transformed
+		applied = append(applied, t.rule.Name)
+	}
+	return obj, applied, nil
+}
+
+// apply returns a transformed copy of the object
+func (t *transformation) apply(obj unstructured.Unstructured) (unstructured.Unstructured, error) {
+	var transformed map[string]interface{}
+	if t.jsonPatch == nil && t.strategicMergePatch == nil {
+		transformed = runtime.DeepCopyJSON(obj.Object)
+	} else {
+		data, err := json.Marshal(obj.Object)
+		if err != nil {
+			return obj, err
+		}
+		if t.jsonPatch != nil {
+			if data, err = t.jsonPatch.Apply(data); err != nil {
+				return obj, err
+			}
+		}
+		if t.strategicMergePatch != nil {
+			if data, err = strategicMergePatch(data, t.strategicMergePatch, obj); err != nil {
+				return obj, err
+			}
+		}
+		if err := json.Unmarshal(data, &transformed); err != nil {
+			return obj, err
+		}
+	}
+	for i := range t.replacements {
+		replaceField(transformed, t.replacements[i].path, &t.replacements[i])
+	}
+	return unstructured.Unstructured{Object: transformed}, nil
+}
+
+// strategicMergePatch patches the object with the patch strategies of its built-in type. Objects of other types, such as custom
+// resources, have none and are patched with a JSON merge patch instead, like kubectl patch does
+func strategicMergePatch(original, patch []byte, obj unstructured.Unstructured) ([]byte, error) {
+	dataStruct, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if runtime.IsNotRegisteredError(err) {
+		return jsonpatch.MergePatch(original, patch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strategicpatch.StrategicMergePatch(original, patch, dataStruct)
+}
+
+// replaceField replaces the string values at the path of the value and returns it. A "*" in the path stands for every item of a
+// list or every value of a map, and a number for the item of a list at that index. Fields the object doesn't have are left out
+func replaceField(value interface{}, path []string, replacement *fieldReplacement) interface{} {
+	if len(path) == 0 {
+		current, ok := value.(string)
+		if !ok {
+			return value
+		}
+		if replacement.regexp == nil {
+			return replacement.value
+		}
+		return replacement.regexp.ReplaceAllString(current, replacement.value)
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if path[0] == "*" {
+			for key, item := range typed {
+				typed[key] = replaceField(item, path[1:], replacement)
+			}
+		} else if item, ok := typed[path[0]]; ok {
+			typed[path[0]] = replaceField(item, path[1:], replacement)
+		}
+	case []interface{}:
+		if path[0] == "*" {
+			for i, item := range typed {
+				typed[i] = replaceField(item, path[1:], replacement)
+			}
+		} else if index, err := strconv.Atoi(path[0]); err == nil && index >= 0 && index < len(typed) {
+			typed[index] = replaceField(typed[index], path[1:], replacement)
+		}
+	}
+	return value
+}
@@ -0,0 +1,306 @@
+package restore
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func objectFromJSON(t *testing.T, data string) unstructured.Unstructured {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &obj); err != nil {
+		t.Fatalf("invalid object %v: %v", data, err)
+	}
+	return unstructured.Unstructured{Object: obj}
+}
+
+const deployment = `{
+	"apiVersion": "apps/v1",
+	"kind": "Deployment",
+	"metadata": {"name": "web", "namespace": "team-a", "labels": {"app": "web"}},
+	"spec": {
+		"replicas": 3,
+		"template": {"spec": {"containers": [
+			{"name": "web", "image": "registry.example.com/web:1.0"},
+			{"name": "sidecar", "image": "registry.example.com/sidecar:1.0"}
+		]}}
+	}
+}`
+
+func TestCompileTransformations(t *testing.T) {
+	selector := v1.ResourceSelector{APIVersion: "apps/v1"}
+	tests := []struct {
+		name    string
+		rules   []v1.TransformationRule
+		wantErr bool
+	}{
+		{
+			name: "patches in JSON and YAML",
+			rules: []v1.TransformationRule{
+				{Name: "json", ResourceSelector: selector, JSONPatch: `[{"op": "replace", "path": "/spec/replicas", "value": 1}]`},
+				{Name: "yaml", ResourceSelector: selector, StrategicMergePatch: "spec:\n  replicas: 1\n"},
+				{Name: "replace", ResourceSelector: selector, FieldReplacements: []v1.FieldReplacement{{Path: "spec.replicas", Regexp: "^3$", Value: "1"}}},
+			},
+		},
+		{
+			name:    "no name",
+			rules:   []v1.TransformationRule{{ResourceSelector: selector, JSONPatch: `[]`}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			rules: []v1.TransformationRule{
+				{Name: "rule", ResourceSelector: selector, JSONPatch: `[]`},
+				{Name: "rule", ResourceSelector: selector, JSONPatch: `[]`},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no apiVersion",
+			rules:   []v1.TransformationRule{{Name: "rule", JSONPatch: `[]`}},
+			wantErr: true,
+		},
+		{
+			name:    "nothing to do",
+			rules:   []v1.TransformationRule{{Name: "rule", ResourceSelector: selector}},
+			wantErr: true,
+		},
+		{
+			name:    "jsonPatch that isn't a list",
+			rules:   []v1.TransformationRule{{Name: "rule", ResourceSelector: selector, JSONPatch: `{"op": "remove"}`}},
+			wantErr: true,
+		},
+		{
+			name:    "strategicMergePatch that isn't an object",
+			rules:   []v1.TransformationRule{{Name: "rule", ResourceSelector: selector, StrategicMergePatch: `[1]`}},
+			wantErr: true,
+		},
+		{
+			name:    "field replacement without a path",
+			rules:   []v1.TransformationRule{{Name: "rule", ResourceSelector: selector, FieldReplacements: []v1.FieldReplacement{{Value: "1"}}}},
+			wantErr: true,
+		},
+		{
+			name: "invalid regexp",
+			rules: []v1.TransformationRule{{Name: "rule", ResourceSelector: selector,
+				FieldReplacements: []v1.FieldReplacement{{Path: "spec", Regexp: "(", Value: "1"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformations, err := compileTransformations(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileTransformations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(transformations) != len(tt.rules) {
+				t.Errorf("compileTransformations() = %v transformations, want %v", len(transformations), len(tt.rules))
+			}
+		})
+	}
+}
+
+func TestTransformationApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    v1.TransformationRule
+		obj     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "jsonPatch",
+			rule: v1.TransformationRule{JSONPatch: `[{"op": "replace", "path": "/spec/replicas", "value": 0}, ` +
+				`{"op": "add", "path": "/metadata/labels/restored", "value": "true"}]`},
+			obj: `{"kind": "Deployment", "metadata": {"name": "web", "labels": {"app": "web"}}, "spec": {"replicas": 3}}`,
+			want: `{"kind": "Deployment", "metadata": {"name": "web", "labels": {"app": "web", "restored": "true"}},
+				"spec": {"replicas": 0}}`,
+		},
+		{
+			name:    "jsonPatch with a failing test",
+			rule:    v1.TransformationRule{JSONPatch: `[{"op": "test", "path": "/spec/replicas", "value": 1}]`},
+			obj:     `{"kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 3}}`,
+			wantErr: true,
+		},
+		{
+			name: "strategicMergePatch merges containers by name",
+			rule: v1.TransformationRule{StrategicMergePatch: "spec:\n  template:\n    spec:\n      containers:\n" +
+				"      - name: sidecar\n        image: mirror.example.com/sidecar:1.0\n"},
+			obj: deployment,
+			want: `{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web", "namespace": "team-a", "labels": {"app": "web"}},
+				"spec": {
+					"replicas": 3,
+					"template": {"spec": {"containers": [
+						{"name": "web", "image": "registry.example.com/web:1.0"},
+						{"name": "sidecar", "image": "mirror.example.com/sidecar:1.0"}
+					]}}
+				}
+			}`,
+		},
+		{
+			name: "strategicMergePatch of a custom resource is a merge patch",
+			rule: v1.TransformationRule{StrategicMergePatch: `{"spec": {"items": [{"name": "b"}], "size": null}}`},
+			obj:  `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w"}, "spec": {"items": [{"name": "a"}], "size": 1}}`,
+			want: `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w"}, "spec": {"items": [{"name": "b"}]}}`,
+		},
+		{
+			name: "field replacement of every item of a list with a regexp",
+			rule: v1.TransformationRule{FieldReplacements: []v1.FieldReplacement{{
+				Path:   "spec.template.spec.containers.*.image",
+				Regexp: "^registry\\.example\\.com/",
+				Value:  "mirror.example.com/",
+			}}},
+			obj: deployment,
+			want: `{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web", "namespace": "team-a", "labels": {"app": "web"}},
+				"spec": {
+					"replicas": 3,
+					"template": {"spec": {"containers": [
+						{"name": "web", "image": "mirror.example.com/web:1.0"},
+						{"name": "sidecar", "image": "mirror.example.com/sidecar:1.0"}
+					]}}
+				}
+			}`,
+		},
+		{
+			name: "field replacement of an item of a list by index",
+			rule: v1.TransformationRule{FieldReplacements: []v1.FieldReplacement{{Path: "spec.items.1", Value: "z"}}},
+			obj:  `{"kind": "Widget", "spec": {"items": ["a", "b", "c"]}}`,
+			want: `{"kind": "Widget", "spec": {"items": ["a", "z", "c"]}}`,
+		},
+		{
+			name: "field replacement of every value of a map",
+			rule: v1.TransformationRule{FieldReplacements: []v1.FieldReplacement{{Path: "data.*", Regexp: "team-a", Value: "team-b"}}},
+			obj:  `{"kind": "ConfigMap", "data": {"url": "http://api.team-a.svc", "port": "80"}}`,
+			want: `{"kind": "ConfigMap", "data": {"url": "http://api.team-b.svc", "port": "80"}}`,
+		},
+		{
+			name: "field replacement leaves out missing fields, indexes out of range and values that aren't strings",
+			rule: v1.TransformationRule{FieldReplacements: []v1.FieldReplacement{
+				{Path: "spec.missing", Value: "x"},
+				{Path: "spec.items.5", Value: "x"},
+				{Path: "spec.replicas", Value: "x"},
+			}},
+			obj:  `{"kind": "Widget", "spec": {"items": ["a"], "replicas": 3}}`,
+			want: `{"kind": "Widget", "spec": {"items": ["a"], "replicas": 3}}`,
+		},
+		{
+			name: "patches are applied before field replacements",
+			rule: v1.TransformationRule{
+				JSONPatch:         `[{"op": "add", "path": "/spec/host", "value": "team-a.example.com"}]`,
+				FieldReplacements: []v1.FieldReplacement{{Path: "spec.host", Regexp: "team-a", Value: "team-b"}},
+			},
+			obj:  `{"kind": "Ingress", "spec": {}}`,
+			want: `{"kind": "Ingress", "spec": {"host": "team-b.example.com"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = "rule"
+			tt.rule.ResourceSelector = v1.ResourceSelector{APIVersion: "v1"}
+			transformations, err := compileTransformations([]v1.TransformationRule{tt.rule})
+			if err != nil {
+				t.Fatalf("compileTransformations() error = %v", err)
+			}
+			obj := objectFromJSON(t, tt.obj)
+			original := obj.DeepCopy()
+			got, err := transformations[0].apply(obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(obj.Object, original.Object) {
+				t.Errorf("apply() changed the original object to %v", obj.Object)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := objectFromJSON(t, tt.want); !reflect.DeepEqual(got.Object, want.Object) {
+				t.Errorf("apply() = %v, want %v", got.Object, want.Object)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	info := objInfo{Name: "web", Namespace: "team-a", GVR: deploymentsGVR}
+	tests := []struct {
+		name        string
+		rules       []v1.TransformationRule
+		wantApplied []string
+		wantImage   string
+		wantErr     bool
+	}{
+		{
+			name: "rules apply in order to the objects they select",
+			rules: []v1.TransformationRule{
+				{Name: "other-kind", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1", Kinds: []string{"statefulsets"}},
+					FieldReplacements: []v1.FieldReplacement{{Path: "spec.template.spec.containers.0.image", Value: "statefulset"}}},
+				{Name: "first", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1", Kinds: []string{"deployments"}},
+					FieldReplacements: []v1.FieldReplacement{{Path: "spec.template.spec.containers.0.image", Value: "first"}}},
+				{Name: "other-namespace", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1", Namespaces: []string{"team-b"}},
+					FieldReplacements: []v1.FieldReplacement{{Path: "spec.template.spec.containers.0.image", Value: "team-b"}}},
+				{Name: "second", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1", Kinds: []string{"Deployment"}},
+					FieldReplacements: []v1.FieldReplacement{{Path: "spec.template.spec.containers.0.image", Regexp: "$", Value: "-second"}}},
+			},
+			wantApplied: []string{"first", "second"},
+			wantImage:   "first-second",
+		},
+		{
+			name: "no rule selects the object",
+			rules: []v1.TransformationRule{
+				{Name: "other-version", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1beta1"},
+					FieldReplacements: []v1.FieldReplacement{{Path: "spec.replicas", Value: "1"}}},
+			},
+			wantImage: "registry.example.com/web:1.0",
+		},
+		{
+			name: "rules can't rename objects",
+			rules: []v1.TransformationRule{
+				{Name: "rename", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1"},
+					FieldReplacements: []v1.FieldReplacement{{Path: "metadata.name", Value: "api"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "rules can't move objects into other namespaces",
+			rules: []v1.TransformationRule{
+				{Name: "move", ResourceSelector: v1.ResourceSelector{APIVersion: "apps/v1"},
+					JSONPatch: `[{"op": "replace", "path": "/metadata/namespace", "value": "team-b"}]`},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := (&handler{}).transforming(&v1.Restore{Spec: v1.RestoreSpec{Transformations: tt.rules}})
+			if err != nil {
+				t.Fatalf("transforming() error = %v", err)
+			}
+			got, applied, err := h.transform(info, objectFromJSON(t, deployment))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("transform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) {
+				t.Errorf("transform() applied %v, want %v", applied, tt.wantApplied)
+			}
+			containers, _, _ := unstructured.NestedSlice(got.Object, "spec", "template", "spec", "containers")
+			if image := containers[0].(map[string]interface{})["image"]; image != tt.wantImage {
+				t.Errorf("transform() set image %v, want %v", image, tt.wantImage)
+			}
+		})
+	}
+}
@@ -23,6 +23,10 @@ func WriteCRD() error {
 		switch bCrd.Name {
 		case "backups.resources.cattle.io":
 			customizeBackup(&bCrd)
+		case "namespacedbackups.resources.cattle.io":
+			customizeNamespacedBackup(&bCrd)
+		case "namespacedrestores.resources.cattle.io":
+			customizeNamespacedRestore(&bCrd)
 		case "resourcesets.resources.cattle.io":
 			customizeResourceSet(&bCrd)
 		case "restores.resources.cattle.io":
@@ -51,6 +55,34 @@ func List() []crd.CRD {
 				WithColumn("Type", ".status.backupType").
 				WithColumn("Latest-Backup", ".status.filename").
 				WithColumn("ResourceSet", ".spec.resourceSetName").
+				WithCustomColumn(apiext.CustomResourceColumnDefinition{Name: "Size", Type: "integer", JSONPath: ".status.lastSuccess.sizeBytes"}).
+				WithColumn("Duration", ".status.lastSuccess.duration").
+				WithCustomColumn(apiext.CustomResourceColumnDefinition{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"}).
+				WithColumn("Status", ".status.conditions[?(@.type==\"Ready\")].message")
+		}),
+		newCRD(&resources.BackupRun{}, func(c crd.CRD) crd.CRD {
+			return c.
+				WithColumn("Backup", ".spec.backupName").
+				WithColumn("Trigger", ".spec.trigger").
+				WithColumn("Phase", ".status.phase").
+				WithColumn("Filename", ".status.filename").
+				WithCustomColumn(apiext.CustomResourceColumnDefinition{Name: "Size", Type: "integer", JSONPath: ".status.sizeBytes"}).
+				WithColumn("Duration", ".status.duration").
+				WithCustomColumn(apiext.CustomResourceColumnDefinition{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"})
+		}),
+		newCRD(&resources.NamespacedBackup{}, func(c crd.CRD) crd.CRD {
+			c.NonNamespace = false
+			return c.
+				WithColumn("Location", ".status.storageLocation").
+				WithColumn("Latest-Backup", ".status.filename").
+				WithCustomColumn(apiext.CustomResourceColumnDefinition{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"}).
+				WithColumn("Status", ".status.conditions[?(@.type==\"Ready\")].message")
+		}),
+		newCRD(&resources.NamespacedRestore{}, func(c crd.CRD) crd.CRD {
+			c.NonNamespace = false
+			return c.
+				WithColumn("Backup-Source", ".status.backupSource").
+				WithColumn("Backup-File", ".spec.backupFilename").
 				WithCustomColumn(apiext.CustomResourceColumnDefinition{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"}).
 				WithColumn("Status", ".status.conditions[?(@.type==\"Ready\")].message")
 		}),
@@ -91,6 +123,94 @@ func customizeBackup(backup *apiext.CustomResourceDefinition) {
 	retentionCount := spec.Properties["retentionCount"]
 	retentionCount.Minimum = &minRetentionCount
 	spec.Properties["retentionCount"] = retentionCount
+	minZero := float64(0)
+	retentionPolicy := spec.Properties["retentionPolicy"]
+	retentionPolicy.Description = "Retention policy, keeps every backup matched by at least one rule. Takes precedence over retentionCount, and also applies to one-time backups"
+	for name, description := range map[string]string{
+		"keepLast":    "Number of newest backups to keep",
+		"maxAgeDays":  "Keep backups taken within this many days",
+		"keepDaily":   "Keep the newest backup of this many days",
+		"keepWeekly":  "Keep the newest backup of this many weeks",
+		"keepMonthly": "Keep the newest backup of this many months",
+		"minKeep":     "Always keep at least this many newest backups, regardless of the other rules",
+		"dryRun":      "Report the backups that would be deleted in status.retentionDryRun, without deleting them",
+		"tagSelector": "Only apply the policy to the backup files with all of these object tags, the Backup's other backup files are kept",
+	} {
+		prop := retentionPolicy.Properties[name]
+		prop.Description = description
+		if prop.Type == "integer" {
+			prop.Minimum = &minZero
+		}
+		retentionPolicy.Properties[name] = prop
+	}
+	spec.Properties["retentionPolicy"] = retentionPolicy
+	quiesce := spec.Properties["quiesce"]
+	quiesce.Description = "Scale down the ResourceSet's controllerReferences while gathering resources, and scale them back up afterwards"
+	spec.Properties["quiesce"] = quiesce
+	deletionPolicy := spec.Properties["deletionPolicy"]
+	deletionPolicy.Description = "Retain (default) keeps the backup files when the Backup CR is deleted, Delete deletes them along with it"
+	deletionPolicy.Enum = []apiext.JSON{{Raw: []byte(`"Retain"`)}, {Raw: []byte(`"Delete"`)}}
+	spec.Properties["deletionPolicy"] = deletionPolicy
+	suspend := spec.Properties["suspend"]
+	suspend.Description = "Don't take any scheduled backups while true, backups triggered with the resources.cattle.io/trigger annotation are still taken"
+	spec.Properties["suspend"] = suspend
+	startingDeadline := spec.Properties["startingDeadlineSeconds"]
+	startingDeadline.Description = "Skip a scheduled backup that couldn't be started within this many seconds of its scheduled time, for example " +
+		"because the operator was down or the Backup was suspended. If unset, a late backup is taken as soon as possible"
+	startingDeadline.Minimum = &minZero
+	spec.Properties["startingDeadlineSeconds"] = startingDeadline
+	timeZone := spec.Properties["timeZone"]
+	timeZone.Description = "IANA time zone to interpret the schedule in, for example Europe/Berlin. Defaults to the operator's local time zone"
+	spec.Properties["timeZone"] = timeZone
+	jitterWindow := spec.Properties["jitterWindowSeconds"]
+	jitterWindow.Description = "Delay scheduled backups by up to this many seconds. The delay is derived from the cluster's kube-system " +
+		"namespace UID and the Backup's name, so it stays the same for every backup but differs between clusters"
+	jitterWindow.Minimum = &minZero
+	spec.Properties["jitterWindowSeconds"] = jitterWindow
+	timeout := spec.Properties["timeoutSeconds"]
+	timeout.Description = "Cancel gathering and uploading a backup that takes longer than this many seconds, and set the Stalled condition. " +
+		"A backup in progress can also be cancelled by changing the resources.cattle.io/cancel annotation"
+	timeout.Minimum = &minZero
+	spec.Properties["timeoutSeconds"] = timeout
+	filenameTemplate := spec.Properties["filenameTemplate"]
+	filenameTemplate.Description = "Go template for the path of backup files within the storage location, without the .tar.gz extension. " +
+		"Available placeholders are .ClusterName, .ClusterUID, .BackupName, .Year, .Month, .Day, .Hour, .Minute, .Second, .Timestamp and .Labels. " +
+		"It must include .Timestamp or .Second, so that each backup gets a file of its own"
+	filenameTemplate.Example = &apiext.JSON{Raw: []byte(`"{{.Year}}/{{.Month}}/{{.Day}}/{{.ClusterName}}-{{.BackupName}}-{{.Timestamp}}"`)}
+	spec.Properties["filenameTemplate"] = filenameTemplate
+	restrictToNamespace := spec.Properties["restrictToNamespace"]
+	restrictToNamespace.Description = "Only back up namespaced resources of this namespace, and read the encryption config Secret from it"
+	spec.Properties["restrictToNamespace"] = restrictToNamespace
+	serviceAccountName := spec.Properties["serviceAccountName"]
+	serviceAccountName.Description = "ServiceAccount to gather resources and quiesce controllers as, so its RBAC permissions apply instead of the operator's. " +
+		"It is looked up in restrictToNamespace if set, and in the operator's namespace otherwise"
+	spec.Properties["serviceAccountName"] = serviceAccountName
+	verifyAfterUpload := spec.Properties["verifyAfterUpload"]
+	verifyAfterUpload.Description = "Read each backup file back from the storage location after storing it, decompressing, decrypting and " +
+		"parsing every entry like a restore would, and record the result in status.lastVerification and the Verified condition"
+	spec.Properties["verifyAfterUpload"] = verifyAfterUpload
+	scrubSchedule := spec.Properties["scrubSchedule"]
+	scrubSchedule.Description = "Cron schedule, in timeZone, for verifying all backup files of the Backup that are still retained. " +
+		"Results are recorded in status.scrub and the Verified condition. A scrub is stopped after timeoutSeconds, or an hour"
+	scrubSchedule.Example = &apiext.JSON{Raw: []byte(`"@weekly"`)}
+	spec.Properties["scrubSchedule"] = scrubSchedule
+	storageTagLabels := spec.Properties["storageTagLabels"]
+	storageTagLabels.Description = "Keys of the Backup's labels to copy into the object tags and user metadata of backup files uploaded to S3, " +
+		"next to the tags the operator always sets for the backup, cluster, ResourceSet, operator version, encryption config and " +
+		"deletion policy"
+	// S3 allows 10 tags per object, the operator sets 9 of them
+	maxTagLabels := int64(1)
+	storageTagLabels.MaxItems = &maxTagLabels
+	spec.Properties["storageTagLabels"] = storageTagLabels
+	for name, description := range map[string]string{
+		"successfulRunsHistoryLimit": "Number of successful BackupRuns to keep, defaults to 3",
+		"failedRunsHistoryLimit":     "Number of failed BackupRuns to keep, defaults to 1",
+	} {
+		prop := spec.Properties[name]
+		prop.Description = description
+		prop.Minimum = &minZero
+		spec.Properties[name] = prop
+	}
 	properties["spec"] = spec
 }
 
@@ -106,10 +226,205 @@ func customizeRestore(restore *apiext.CustomResourceDefinition) {
 	maxDeleteTimeout := float64(10)
 	properties := restore.Spec.Validation.OpenAPIV3Schema.Properties
 	spec := properties["spec"]
-	spec.Required = []string{"backupFilename"}
 	deleteTimeout := spec.Properties["deleteTimeoutSeconds"]
 	deleteTimeout.Maximum = &maxDeleteTimeout
 	spec.Properties["deleteTimeoutSeconds"] = deleteTimeout
+	restrictToNamespace := spec.Properties["restrictToNamespace"]
+	restrictToNamespace.Description = "Only restore and prune namespaced resources of this namespace, ignoring everything else in the backup file, " +
+		"and read the encryption config Secret from it"
+	spec.Properties["restrictToNamespace"] = restrictToNamespace
+	serviceAccountName := spec.Properties["serviceAccountName"]
+	serviceAccountName.Description = "ServiceAccount to restore and prune resources as, so its RBAC permissions apply instead of the operator's. " +
+		"It is looked up in restrictToNamespace if set, and in the operator's namespace otherwise"
+	spec.Properties["serviceAccountName"] = serviceAccountName
+	spec.Properties["backupTagSelector"] = backupTagSelectorProperty(spec.Properties["backupTagSelector"])
+	spec.Properties["dryRun"] = dryRunProperty(spec.Properties["dryRun"])
+	includeResourceSelectors := spec.Properties["includeResourceSelectors"]
+	includeResourceSelectors.Description = "Only restore the objects of the backup file matching any of these selectors, which follow the " +
+		"rules of a ResourceSet's resourceSelectors. Owners that aren't restored must already exist. Pruning is limited to the selected objects"
+	excludeResourceSelectors := spec.Properties["excludeResourceSelectors"]
+	excludeResourceSelectors.Description = "Don't restore or prune the objects matching any of these selectors, even if they are included"
+	for _, selectors := range []*apiext.JSONSchemaProps{&includeResourceSelectors, &excludeResourceSelectors} {
+		if selectors.Items != nil && selectors.Items.Schema != nil {
+			selectors.Items.Schema.Required = []string{"apiVersion"}
+		}
+	}
+	namespaceMapping := spec.Properties["namespaceMapping"]
+	namespaceMapping.Description = "Restore the objects of the namespaces in the backup file given as keys into the namespaces given as values, " +
+		"renaming the Namespaces and rewriting the references of the moved objects to them, such as RoleBinding subjects. " +
+		"Cluster-scoped objects are restored unchanged. " +
+		"restrictToNamespace, the resource selectors and pruning apply to the mapped namespaces, and the objects left in the " +
+		"namespaces given as keys are never pruned"
+	namespaceMapping.Example = &apiext.JSON{Raw: []byte(`{"team-a": "team-a-staging"}`)}
+	spec.Properties["namespaceMapping"] = namespaceMapping
+	spec.Properties["includeResourceSelectors"] = includeResourceSelectors
+	spec.Properties["excludeResourceSelectors"] = excludeResourceSelectors
+	spec.Properties["transformations"] = transformationsProperty(spec.Properties["transformations"])
+	conflictPolicies := []apiext.JSON{{Raw: []byte(`"Overwrite"`)}, {Raw: []byte(`"SkipIfExists"`)}, {Raw: []byte(`"FailIfExists"`)},
+		{Raw: []byte(`"SkipIfModifiedAfterBackup"`)}}
+	conflictPolicy := spec.Properties["conflictPolicy"]
+	conflictPolicy.Description = "What to do with objects of the backup file that already exist: Overwrite (default) updates them, " +
+		"SkipIfExists leaves them as they are, FailIfExists fails the restore, and SkipIfModifiedAfterBackup leaves them as they are if " +
+		"their managed fields show a change other than to their status after the backup was taken. " +
+		"Every decision is recorded in the ConfigMap named in status.report"
+	conflictPolicy.Enum = conflictPolicies
+	spec.Properties["conflictPolicy"] = conflictPolicy
+	resourceConflictPolicies := spec.Properties["resourceConflictPolicies"]
+	resourceConflictPolicies.Description = "Override conflictPolicy for the objects of the given resources"
+	if resourceConflictPolicies.Items != nil && resourceConflictPolicies.Items.Schema != nil {
+		resourceConflictPolicy := resourceConflictPolicies.Items.Schema
+		resourceConflictPolicy.Required = []string{"apiVersion", "resource", "policy"}
+		resource := resourceConflictPolicy.Properties["resource"]
+		resource.Description = "Plural name of the resource, such as deployments"
+		resourceConflictPolicy.Properties["resource"] = resource
+		policy := resourceConflictPolicy.Properties["policy"]
+		policy.Enum = conflictPolicies
+		resourceConflictPolicy.Properties["policy"] = policy
+	}
+	spec.Properties["resourceConflictPolicies"] = resourceConflictPolicies
+	applyMode := spec.Properties["applyMode"]
+	applyMode.Description = "Update (default) creates objects or replaces them entirely, ServerSideApply restores them with server-side " +
+		"apply as the backup-restore-operator field manager, status included, keeping the fields of the live objects that other " +
+		"managers own and the backup doesn't set"
+	applyMode.Enum = []apiext.JSON{{Raw: []byte(`"Update"`)}, {Raw: []byte(`"ServerSideApply"`)}}
+	spec.Properties["applyMode"] = applyMode
+	forceConflicts := spec.Properties["forceConflicts"]
+	forceConflicts.Description = "With applyMode ServerSideApply, take over the fields set in the backup that other field managers own " +
+		"instead of failing the restore with a conflict"
+	spec.Properties["forceConflicts"] = forceConflicts
+	recreateKinds := spec.Properties["recreateKinds"]
+	recreateKinds.Description = "Kinds whose existing objects are deleted and created again from the backup when restoring them fails " +
+		"because immutable fields differ, such as the clusterIP of a Service or the selector of a Job. Their dependents are orphaned, " +
+		"and the restore waits up to deleteTimeoutSeconds, or 10 seconds, for their finalizers. Beware that recreating " +
+		"PersistentVolumeClaims can release their volumes"
+	if recreateKinds.Items != nil && recreateKinds.Items.Schema != nil {
+		recreateKinds.Items.Schema.Required = []string{"apiVersion", "kind"}
+	}
+	recreateKinds.Example = &apiext.JSON{Raw: []byte(`[{"apiVersion": "v1", "kind": "Service"}, {"apiVersion": "batch/v1", "kind": "Job"}]`)}
+	spec.Properties["recreateKinds"] = recreateKinds
+	minConcurrency, maxConcurrency := float64(1), float64(100)
+	concurrency := spec.Properties["concurrency"]
+	concurrency.Description = "Number of objects to restore at once, 25 by default. The owners of an object are always restored before it"
+	concurrency.Minimum = &minConcurrency
+	concurrency.Maximum = &maxConcurrency
+	spec.Properties["concurrency"] = concurrency
+	resourcePriority := spec.Properties["resourcePriority"]
+	resourcePriority.Description = "Resources to restore in waves, in the order listed, after the CRDs and namespaces and before the " +
+		"resources not listed, overriding the RESTORE_RESOURCE_PRIORITY of the operator. Namespaces are always restored first, " +
+		"wherever they are listed. Each resource is written as resource.group, or resource for " +
+		"the core group. Objects are restored in the wave of their last owner if it comes later, and the ownerRefs still order the " +
+		"objects within each wave"
+	resourcePriority.Example = &apiext.JSON{Raw: []byte(`["namespaces", "serviceaccounts", "services", ` +
+		`"rolebindings.rbac.authorization.k8s.io", "validatingwebhookconfigurations.admissionregistration.k8s.io"]`)}
+	spec.Properties["resourcePriority"] = resourcePriority
+	properties["spec"] = spec
+}
+
+// transformationsProperty describes the rules changing objects before they are restored
+func transformationsProperty(transformations apiext.JSONSchemaProps) apiext.JSONSchemaProps {
+	transformations.Description = "Rules changing the objects matching their resourceSelector before they are restored, applied in order. " +
+		"Each rule applied is recorded in the ConfigMap named in status.report, or in the plan of a dry run"
+	if transformations.Items == nil || transformations.Items.Schema == nil {
+		return transformations
+	}
+	rule := transformations.Items.Schema
+	rule.Required = []string{"name", "resourceSelector"}
+	resourceSelector := rule.Properties["resourceSelector"]
+	resourceSelector.Description = "Objects to transform, following the rules of a ResourceSet's resourceSelectors. " +
+		"Namespaces are matched after namespaceMapping"
+	resourceSelector.Required = []string{"apiVersion"}
+	rule.Properties["resourceSelector"] = resourceSelector
+	jsonPatch := rule.Properties["jsonPatch"]
+	jsonPatch.Description = "JSON patch (RFC 6902) to apply, in JSON or YAML"
+	jsonPatch.Example = &apiext.JSON{Raw: []byte(`"[{\"op\": \"replace\", \"path\": \"/spec/storageClassName\", \"value\": \"standard\"}]"`)}
+	rule.Properties["jsonPatch"] = jsonPatch
+	strategicMergePatch := rule.Properties["strategicMergePatch"]
+	strategicMergePatch.Description = "Strategic merge patch to apply, in JSON or YAML. Objects of types other than the built-in " +
+		"Kubernetes types get a JSON merge patch instead"
+	rule.Properties["strategicMergePatch"] = strategicMergePatch
+	fieldReplacements := rule.Properties["fieldReplacements"]
+	fieldReplacements.Description = "Replace string fields of the object, leaving out the fields it doesn't have"
+	if fieldReplacements.Items != nil && fieldReplacements.Items.Schema != nil {
+		replacement := fieldReplacements.Items.Schema
+		replacement.Required = []string{"path", "value"}
+		path := replacement.Properties["path"]
+		path.Description = "Dot-separated path of the fields, where * stands for every item of a list or value of a map and a number " +
+			"for the item of a list at that index. Use a patch for map keys containing dots"
+		path.Example = &apiext.JSON{Raw: []byte(`"spec.template.spec.containers.*.image"`)}
+		replacement.Properties["path"] = path
+		regexp := replacement.Properties["regexp"]
+		regexp.Description = "Only replace the parts of the values matching this regular expression, value can refer to its groups as $1"
+		regexp.Example = &apiext.JSON{Raw: []byte(`"^registry.example.com/"`)}
+		replacement.Properties["regexp"] = regexp
+	}
+	rule.Properties["fieldReplacements"] = fieldReplacements
+	return transformations
+}
+
+// backupTagSelectorProperty describes selecting the backup file to restore by its object tags, which replaces backupFilename
+func backupTagSelectorProperty(backupTagSelector apiext.JSONSchemaProps) apiext.JSONSchemaProps {
+	backupTagSelector.Description = "Restore the newest backup file in the storage location with all of these object tags, " +
+		"instead of the one named by backupFilename. Labels copied from the Backup are matched with their own keys"
+	backupTagSelector.Example = &apiext.JSON{Raw: []byte(`{"resources.cattle.io/backup-name": "nightly"}`)}
+	return backupTagSelector
+}
+
+// dryRunProperty describes planning a restore without changing anything
+func dryRunProperty(dryRun apiext.JSONSchemaProps) apiext.JSONSchemaProps {
+	dryRun.Description = "Only compute which objects the restore would create, update, leave unchanged or prune, without changing anything. " +
+		"The counts are recorded in status.dryRunPlan, the full plan with the changed fields of every object in the ConfigMap it names"
+	return dryRun
+}
+
+func customizeNamespacedBackup(backup *apiext.CustomResourceDefinition) {
+	properties := backup.Spec.Validation.OpenAPIV3Schema.Properties
+	spec := properties["spec"]
+	spec.Required = []string{"resourceSelectors", "storageLocation", "serviceAccountName"}
+	resourceSelectors := spec.Properties["resourceSelectors"]
+	resourceSelectors.Description = "Resources of the NamespacedBackup's namespace to back up, selectors can't name other namespaces"
+	if resourceSelectors.Items != nil && resourceSelectors.Items.Schema != nil {
+		resourceSelectors.Items.Schema.Required = []string{"apiVersion"}
+	}
+	spec.Properties["resourceSelectors"] = resourceSelectors
+	storageLocation := spec.Properties["storageLocation"]
+	storageLocation.Description = "S3 storage location, its credential Secret is read from the NamespacedBackup's namespace"
+	storageLocation.Required = []string{"s3"}
+	spec.Properties["storageLocation"] = storageLocation
+	encryptionConfig := spec.Properties["encryptionConfigSecretName"]
+	encryptionConfig.Description = "Name of the Secret in the NamespacedBackup's namespace containing the encryption config"
+	spec.Properties["encryptionConfigSecretName"] = encryptionConfig
+	minRetentionCount := float64(1)
+	retentionCount := spec.Properties["retentionCount"]
+	retentionCount.Minimum = &minRetentionCount
+	spec.Properties["retentionCount"] = retentionCount
+	serviceAccountName := spec.Properties["serviceAccountName"]
+	serviceAccountName.Description = "ServiceAccount of the NamespacedBackup's namespace to gather resources as, so its RBAC " +
+		"permissions limit what is backed up. Namespaced backups never run with the operator's own permissions"
+	spec.Properties["serviceAccountName"] = serviceAccountName
+	properties["spec"] = spec
+}
+
+func customizeNamespacedRestore(restore *apiext.CustomResourceDefinition) {
+	maxDeleteTimeout := float64(10)
+	properties := restore.Spec.Validation.OpenAPIV3Schema.Properties
+	spec := properties["spec"]
+	spec.Required = []string{"storageLocation", "serviceAccountName"}
+	storageLocation := spec.Properties["storageLocation"]
+	storageLocation.Description = "S3 storage location, its credential Secret is read from the NamespacedRestore's namespace"
+	storageLocation.Required = []string{"s3"}
+	spec.Properties["storageLocation"] = storageLocation
+	encryptionConfig := spec.Properties["encryptionConfigSecretName"]
+	encryptionConfig.Description = "Name of the Secret in the NamespacedRestore's namespace containing the encryption config"
+	spec.Properties["encryptionConfigSecretName"] = encryptionConfig
+	deleteTimeout := spec.Properties["deleteTimeoutSeconds"]
+	deleteTimeout.Maximum = &maxDeleteTimeout
+	spec.Properties["deleteTimeoutSeconds"] = deleteTimeout
+	serviceAccountName := spec.Properties["serviceAccountName"]
+	serviceAccountName.Description = "ServiceAccount of the NamespacedRestore's namespace to restore and prune resources as, so its " +
+		"RBAC permissions limit what is restored. Namespaced restores never run with the operator's own permissions"
+	spec.Properties["serviceAccountName"] = serviceAccountName
+	spec.Properties["backupTagSelector"] = backupTagSelectorProperty(spec.Properties["backupTagSelector"])
+	spec.Properties["dryRun"] = dryRunProperty(spec.Properties["dryRun"])
 	properties["spec"] = spec
 }
 
@@ -0,0 +1,364 @@
+/*
+Copyright 2020 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/generic"
+	"github.com/rancher/wrangler/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type BackupRunHandler func(string, *v1.BackupRun) (*v1.BackupRun, error)
+
+type BackupRunController interface {
+	generic.ControllerMeta
+	BackupRunClient
+
+	OnChange(ctx context.Context, name string, sync BackupRunHandler)
+	OnRemove(ctx context.Context, name string, sync BackupRunHandler)
+	Enqueue(name string)
+	EnqueueAfter(name string, duration time.Duration)
+
+	Cache() BackupRunCache
+}
+
+type BackupRunClient interface {
+	Create(*v1.BackupRun) (*v1.BackupRun, error)
+	Update(*v1.BackupRun) (*v1.BackupRun, error)
+	UpdateStatus(*v1.BackupRun) (*v1.BackupRun, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.BackupRun, error)
+	List(opts metav1.ListOptions) (*v1.BackupRunList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.BackupRun, err error)
+}
+
+type BackupRunCache interface {
+	Get(name string) (*v1.BackupRun, error)
+	List(selector labels.Selector) ([]*v1.BackupRun, error)
+
+	AddIndexer(indexName string, indexer BackupRunIndexer)
+	GetByIndex(indexName, key string) ([]*v1.BackupRun, error)
+}
+
+type BackupRunIndexer func(obj *v1.BackupRun) ([]string, error)
+
+type backupRunController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewBackupRunController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) BackupRunController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &backupRunController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromBackupRunHandlerToHandler(sync BackupRunHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1.BackupRun
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1.BackupRun))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *backupRunController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1.BackupRun))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateBackupRunDeepCopyOnChange(client BackupRunClient, obj *v1.BackupRun, handler func(obj *v1.BackupRun) (*v1.BackupRun, error)) (*v1.BackupRun, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *backupRunController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *backupRunController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *backupRunController) OnChange(ctx context.Context, name string, sync BackupRunHandler) {
+	c.AddGenericHandler(ctx, name, FromBackupRunHandlerToHandler(sync))
+}
+
+func (c *backupRunController) OnRemove(ctx context.Context, name string, sync BackupRunHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromBackupRunHandlerToHandler(sync)))
+}
+
+func (c *backupRunController) Enqueue(name string) {
+	c.controller.Enqueue("", name)
+}
+
+func (c *backupRunController) EnqueueAfter(name string, duration time.Duration) {
+	c.controller.EnqueueAfter("", name, duration)
+}
+
+func (c *backupRunController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *backupRunController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *backupRunController) Cache() BackupRunCache {
+	return &backupRunCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *backupRunController) Create(obj *v1.BackupRun) (*v1.BackupRun, error) {
+	result := &v1.BackupRun{}
+	return result, c.client.Create(context.TODO(), "", obj, result, metav1.CreateOptions{})
+}
+
+func (c *backupRunController) Update(obj *v1.BackupRun) (*v1.BackupRun, error) {
+	result := &v1.BackupRun{}
+	return result, c.client.Update(context.TODO(), "", obj, result, metav1.UpdateOptions{})
+}
+
+func (c *backupRunController) UpdateStatus(obj *v1.BackupRun) (*v1.BackupRun, error) {
+	result := &v1.BackupRun{}
+	return result, c.client.UpdateStatus(context.TODO(), "", obj, result, metav1.UpdateOptions{})
+}
+
+func (c *backupRunController) Delete(name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), "", name, *options)
+}
+
+func (c *backupRunController) Get(name string, options metav1.GetOptions) (*v1.BackupRun, error) {
+	result := &v1.BackupRun{}
+	return result, c.client.Get(context.TODO(), "", name, result, options)
+}
+
+func (c *backupRunController) List(opts metav1.ListOptions) (*v1.BackupRunList, error) {
+	result := &v1.BackupRunList{}
+	return result, c.client.List(context.TODO(), "", result, opts)
+}
+
+func (c *backupRunController) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), "", opts)
+}
+
+func (c *backupRunController) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*v1.BackupRun, error) {
+	result := &v1.BackupRun{}
+	return result, c.client.Patch(context.TODO(), "", name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type backupRunCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *backupRunCache) Get(name string) (*v1.BackupRun, error) {
+	obj, exists, err := c.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1.BackupRun), nil
+}
+
+func (c *backupRunCache) List(selector labels.Selector) (ret []*v1.BackupRun, err error) {
+
+	err = cache.ListAll(c.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.BackupRun))
+	})
+
+	return ret, err
+}
+
+func (c *backupRunCache) AddIndexer(indexName string, indexer BackupRunIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1.BackupRun))
+		},
+	}))
+}
+
+func (c *backupRunCache) GetByIndex(indexName, key string) (result []*v1.BackupRun, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1.BackupRun, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1.BackupRun))
+	}
+	return result, nil
+}
+
+type BackupRunStatusHandler func(obj *v1.BackupRun, status v1.BackupRunStatus) (v1.BackupRunStatus, error)
+
+type BackupRunGeneratingHandler func(obj *v1.BackupRun, status v1.BackupRunStatus) ([]runtime.Object, v1.BackupRunStatus, error)
+
+func RegisterBackupRunStatusHandler(ctx context.Context, controller BackupRunController, condition condition.Cond, name string, handler BackupRunStatusHandler) {
+	statusHandler := &backupRunStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, FromBackupRunHandlerToHandler(statusHandler.sync))
+}
+
+func RegisterBackupRunGeneratingHandler(ctx context.Context, controller BackupRunController, apply apply.Apply,
+	condition condition.Cond, name string, handler BackupRunGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &backupRunGeneratingHandler{
+		BackupRunGeneratingHandler: handler,
+		apply:                      apply,
+		name:                       name,
+		gvk:                        controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterBackupRunStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type backupRunStatusHandler struct {
+	client    BackupRunClient
+	condition condition.Cond
+	handler   BackupRunStatusHandler
+}
+
+func (a *backupRunStatusHandler) sync(key string, obj *v1.BackupRun) (*v1.BackupRun, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		var newErr error
+		obj.Status = newStatus
+		obj, newErr = a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+	}
+	return obj, err
+}
+
+type backupRunGeneratingHandler struct {
+	BackupRunGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+}
+
+func (a *backupRunGeneratingHandler) Remove(key string, obj *v1.BackupRun) (*v1.BackupRun, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1.BackupRun{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+func (a *backupRunGeneratingHandler) Handle(obj *v1.BackupRun, status v1.BackupRunStatus) (v1.BackupRunStatus, error) {
+	objs, newStatus, err := a.BackupRunGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+
+	return newStatus, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+}
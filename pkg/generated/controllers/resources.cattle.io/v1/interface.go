@@ -31,6 +31,9 @@ func init() {
 
 type Interface interface {
 	Backup() BackupController
+	BackupRun() BackupRunController
+	NamespacedBackup() NamespacedBackupController
+	NamespacedRestore() NamespacedRestoreController
 	ResourceSet() ResourceSetController
 	Restore() RestoreController
 }
@@ -48,6 +51,15 @@ type version struct {
 func (c *version) Backup() BackupController {
 	return NewBackupController(schema.GroupVersionKind{Group: "resources.cattle.io", Version: "v1", Kind: "Backup"}, "backups", false, c.controllerFactory)
 }
+func (c *version) BackupRun() BackupRunController {
+	return NewBackupRunController(schema.GroupVersionKind{Group: "resources.cattle.io", Version: "v1", Kind: "BackupRun"}, "backupruns", false, c.controllerFactory)
+}
+func (c *version) NamespacedBackup() NamespacedBackupController {
+	return NewNamespacedBackupController(schema.GroupVersionKind{Group: "resources.cattle.io", Version: "v1", Kind: "NamespacedBackup"}, "namespacedbackups", true, c.controllerFactory)
+}
+func (c *version) NamespacedRestore() NamespacedRestoreController {
+	return NewNamespacedRestoreController(schema.GroupVersionKind{Group: "resources.cattle.io", Version: "v1", Kind: "NamespacedRestore"}, "namespacedrestores", true, c.controllerFactory)
+}
 func (c *version) ResourceSet() ResourceSetController {
 	return NewResourceSetController(schema.GroupVersionKind{Group: "resources.cattle.io", Version: "v1", Kind: "ResourceSet"}, "resourcesets", false, c.controllerFactory)
 }
@@ -0,0 +1,364 @@
+/*
+Copyright 2020 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/generic"
+	"github.com/rancher/wrangler/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type NamespacedBackupHandler func(string, *v1.NamespacedBackup) (*v1.NamespacedBackup, error)
+
+type NamespacedBackupController interface {
+	generic.ControllerMeta
+	NamespacedBackupClient
+
+	OnChange(ctx context.Context, name string, sync NamespacedBackupHandler)
+	OnRemove(ctx context.Context, name string, sync NamespacedBackupHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() NamespacedBackupCache
+}
+
+type NamespacedBackupClient interface {
+	Create(*v1.NamespacedBackup) (*v1.NamespacedBackup, error)
+	Update(*v1.NamespacedBackup) (*v1.NamespacedBackup, error)
+	UpdateStatus(*v1.NamespacedBackup) (*v1.NamespacedBackup, error)
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1.NamespacedBackup, error)
+	List(namespace string, opts metav1.ListOptions) (*v1.NamespacedBackupList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.NamespacedBackup, err error)
+}
+
+type NamespacedBackupCache interface {
+	Get(namespace, name string) (*v1.NamespacedBackup, error)
+	List(namespace string, selector labels.Selector) ([]*v1.NamespacedBackup, error)
+
+	AddIndexer(indexName string, indexer NamespacedBackupIndexer)
+	GetByIndex(indexName, key string) ([]*v1.NamespacedBackup, error)
+}
+
+type NamespacedBackupIndexer func(obj *v1.NamespacedBackup) ([]string, error)
+
+type namespacedBackupController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewNamespacedBackupController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) NamespacedBackupController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &namespacedBackupController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromNamespacedBackupHandlerToHandler(sync NamespacedBackupHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1.NamespacedBackup
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1.NamespacedBackup))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *namespacedBackupController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1.NamespacedBackup))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateNamespacedBackupDeepCopyOnChange(client NamespacedBackupClient, obj *v1.NamespacedBackup, handler func(obj *v1.NamespacedBackup) (*v1.NamespacedBackup, error)) (*v1.NamespacedBackup, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *namespacedBackupController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *namespacedBackupController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *namespacedBackupController) OnChange(ctx context.Context, name string, sync NamespacedBackupHandler) {
+	c.AddGenericHandler(ctx, name, FromNamespacedBackupHandlerToHandler(sync))
+}
+
+func (c *namespacedBackupController) OnRemove(ctx context.Context, name string, sync NamespacedBackupHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromNamespacedBackupHandlerToHandler(sync)))
+}
+
+func (c *namespacedBackupController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *namespacedBackupController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *namespacedBackupController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *namespacedBackupController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *namespacedBackupController) Cache() NamespacedBackupCache {
+	return &namespacedBackupCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *namespacedBackupController) Create(obj *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	result := &v1.NamespacedBackup{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *namespacedBackupController) Update(obj *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	result := &v1.NamespacedBackup{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *namespacedBackupController) UpdateStatus(obj *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	result := &v1.NamespacedBackup{}
+	return result, c.client.UpdateStatus(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *namespacedBackupController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *namespacedBackupController) Get(namespace, name string, options metav1.GetOptions) (*v1.NamespacedBackup, error) {
+	result := &v1.NamespacedBackup{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *namespacedBackupController) List(namespace string, opts metav1.ListOptions) (*v1.NamespacedBackupList, error) {
+	result := &v1.NamespacedBackupList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *namespacedBackupController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *namespacedBackupController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1.NamespacedBackup, error) {
+	result := &v1.NamespacedBackup{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type namespacedBackupCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *namespacedBackupCache) Get(namespace, name string) (*v1.NamespacedBackup, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1.NamespacedBackup), nil
+}
+
+func (c *namespacedBackupCache) List(namespace string, selector labels.Selector) (ret []*v1.NamespacedBackup, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NamespacedBackup))
+	})
+
+	return ret, err
+}
+
+func (c *namespacedBackupCache) AddIndexer(indexName string, indexer NamespacedBackupIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1.NamespacedBackup))
+		},
+	}))
+}
+
+func (c *namespacedBackupCache) GetByIndex(indexName, key string) (result []*v1.NamespacedBackup, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1.NamespacedBackup, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1.NamespacedBackup))
+	}
+	return result, nil
+}
+
+type NamespacedBackupStatusHandler func(obj *v1.NamespacedBackup, status v1.BackupStatus) (v1.BackupStatus, error)
+
+type NamespacedBackupGeneratingHandler func(obj *v1.NamespacedBackup, status v1.BackupStatus) ([]runtime.Object, v1.BackupStatus, error)
+
+func RegisterNamespacedBackupStatusHandler(ctx context.Context, controller NamespacedBackupController, condition condition.Cond, name string, handler NamespacedBackupStatusHandler) {
+	statusHandler := &namespacedBackupStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, FromNamespacedBackupHandlerToHandler(statusHandler.sync))
+}
+
+func RegisterNamespacedBackupGeneratingHandler(ctx context.Context, controller NamespacedBackupController, apply apply.Apply,
+	condition condition.Cond, name string, handler NamespacedBackupGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &namespacedBackupGeneratingHandler{
+		NamespacedBackupGeneratingHandler: handler,
+		apply:                             apply,
+		name:                              name,
+		gvk:                               controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterNamespacedBackupStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type namespacedBackupStatusHandler struct {
+	client    NamespacedBackupClient
+	condition condition.Cond
+	handler   NamespacedBackupStatusHandler
+}
+
+func (a *namespacedBackupStatusHandler) sync(key string, obj *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		var newErr error
+		obj.Status = newStatus
+		obj, newErr = a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+	}
+	return obj, err
+}
+
+type namespacedBackupGeneratingHandler struct {
+	NamespacedBackupGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+}
+
+func (a *namespacedBackupGeneratingHandler) Remove(key string, obj *v1.NamespacedBackup) (*v1.NamespacedBackup, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1.NamespacedBackup{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+func (a *namespacedBackupGeneratingHandler) Handle(obj *v1.NamespacedBackup, status v1.BackupStatus) (v1.BackupStatus, error) {
+	objs, newStatus, err := a.NamespacedBackupGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+
+	return newStatus, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+}
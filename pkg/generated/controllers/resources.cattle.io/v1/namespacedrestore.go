@@ -0,0 +1,364 @@
+/*
+Copyright 2020 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/generic"
+	"github.com/rancher/wrangler/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type NamespacedRestoreHandler func(string, *v1.NamespacedRestore) (*v1.NamespacedRestore, error)
+
+type NamespacedRestoreController interface {
+	generic.ControllerMeta
+	NamespacedRestoreClient
+
+	OnChange(ctx context.Context, name string, sync NamespacedRestoreHandler)
+	OnRemove(ctx context.Context, name string, sync NamespacedRestoreHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() NamespacedRestoreCache
+}
+
+type NamespacedRestoreClient interface {
+	Create(*v1.NamespacedRestore) (*v1.NamespacedRestore, error)
+	Update(*v1.NamespacedRestore) (*v1.NamespacedRestore, error)
+	UpdateStatus(*v1.NamespacedRestore) (*v1.NamespacedRestore, error)
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1.NamespacedRestore, error)
+	List(namespace string, opts metav1.ListOptions) (*v1.NamespacedRestoreList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.NamespacedRestore, err error)
+}
+
+type NamespacedRestoreCache interface {
+	Get(namespace, name string) (*v1.NamespacedRestore, error)
+	List(namespace string, selector labels.Selector) ([]*v1.NamespacedRestore, error)
+
+	AddIndexer(indexName string, indexer NamespacedRestoreIndexer)
+	GetByIndex(indexName, key string) ([]*v1.NamespacedRestore, error)
+}
+
+type NamespacedRestoreIndexer func(obj *v1.NamespacedRestore) ([]string, error)
+
+type namespacedRestoreController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewNamespacedRestoreController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) NamespacedRestoreController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &namespacedRestoreController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromNamespacedRestoreHandlerToHandler(sync NamespacedRestoreHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1.NamespacedRestore
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1.NamespacedRestore))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *namespacedRestoreController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1.NamespacedRestore))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateNamespacedRestoreDeepCopyOnChange(client NamespacedRestoreClient, obj *v1.NamespacedRestore, handler func(obj *v1.NamespacedRestore) (*v1.NamespacedRestore, error)) (*v1.NamespacedRestore, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *namespacedRestoreController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *namespacedRestoreController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *namespacedRestoreController) OnChange(ctx context.Context, name string, sync NamespacedRestoreHandler) {
+	c.AddGenericHandler(ctx, name, FromNamespacedRestoreHandlerToHandler(sync))
+}
+
+func (c *namespacedRestoreController) OnRemove(ctx context.Context, name string, sync NamespacedRestoreHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromNamespacedRestoreHandlerToHandler(sync)))
+}
+
+func (c *namespacedRestoreController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *namespacedRestoreController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *namespacedRestoreController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *namespacedRestoreController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *namespacedRestoreController) Cache() NamespacedRestoreCache {
+	return &namespacedRestoreCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *namespacedRestoreController) Create(obj *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	result := &v1.NamespacedRestore{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *namespacedRestoreController) Update(obj *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	result := &v1.NamespacedRestore{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *namespacedRestoreController) UpdateStatus(obj *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	result := &v1.NamespacedRestore{}
+	return result, c.client.UpdateStatus(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *namespacedRestoreController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *namespacedRestoreController) Get(namespace, name string, options metav1.GetOptions) (*v1.NamespacedRestore, error) {
+	result := &v1.NamespacedRestore{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *namespacedRestoreController) List(namespace string, opts metav1.ListOptions) (*v1.NamespacedRestoreList, error) {
+	result := &v1.NamespacedRestoreList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *namespacedRestoreController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *namespacedRestoreController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1.NamespacedRestore, error) {
+	result := &v1.NamespacedRestore{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type namespacedRestoreCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *namespacedRestoreCache) Get(namespace, name string) (*v1.NamespacedRestore, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1.NamespacedRestore), nil
+}
+
+func (c *namespacedRestoreCache) List(namespace string, selector labels.Selector) (ret []*v1.NamespacedRestore, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.NamespacedRestore))
+	})
+
+	return ret, err
+}
+
+func (c *namespacedRestoreCache) AddIndexer(indexName string, indexer NamespacedRestoreIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1.NamespacedRestore))
+		},
+	}))
+}
+
+func (c *namespacedRestoreCache) GetByIndex(indexName, key string) (result []*v1.NamespacedRestore, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1.NamespacedRestore, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1.NamespacedRestore))
+	}
+	return result, nil
+}
+
+type NamespacedRestoreStatusHandler func(obj *v1.NamespacedRestore, status v1.RestoreStatus) (v1.RestoreStatus, error)
+
+type NamespacedRestoreGeneratingHandler func(obj *v1.NamespacedRestore, status v1.RestoreStatus) ([]runtime.Object, v1.RestoreStatus, error)
+
+func RegisterNamespacedRestoreStatusHandler(ctx context.Context, controller NamespacedRestoreController, condition condition.Cond, name string, handler NamespacedRestoreStatusHandler) {
+	statusHandler := &namespacedRestoreStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, FromNamespacedRestoreHandlerToHandler(statusHandler.sync))
+}
+
+func RegisterNamespacedRestoreGeneratingHandler(ctx context.Context, controller NamespacedRestoreController, apply apply.Apply,
+	condition condition.Cond, name string, handler NamespacedRestoreGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &namespacedRestoreGeneratingHandler{
+		NamespacedRestoreGeneratingHandler: handler,
+		apply:                              apply,
+		name:                               name,
+		gvk:                                controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterNamespacedRestoreStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type namespacedRestoreStatusHandler struct {
+	client    NamespacedRestoreClient
+	condition condition.Cond
+	handler   NamespacedRestoreStatusHandler
+}
+
+func (a *namespacedRestoreStatusHandler) sync(key string, obj *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		var newErr error
+		obj.Status = newStatus
+		obj, newErr = a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+	}
+	return obj, err
+}
+
+type namespacedRestoreGeneratingHandler struct {
+	NamespacedRestoreGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+}
+
+func (a *namespacedRestoreGeneratingHandler) Remove(key string, obj *v1.NamespacedRestore) (*v1.NamespacedRestore, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1.NamespacedRestore{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+func (a *namespacedRestoreGeneratingHandler) Handle(obj *v1.NamespacedRestore, status v1.RestoreStatus) (v1.RestoreStatus, error) {
+	objs, newStatus, err := a.NamespacedRestoreGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+
+	return newStatus, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+}
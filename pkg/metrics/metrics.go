@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	metricsNamespace = "backup_restore_operator"
+
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+var (
+	backupsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "backups_started_total",
+		Help:      "Number of backups started, per Backup CR",
+	}, []string{"backup"})
+
+	backupsCompleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "backups_completed_total",
+		Help:      "Number of backups completed, per Backup CR and result",
+	}, []string{"backup", "result"})
+
+	backupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "backup_duration_seconds",
+		Help:      "Duration of backups, per Backup CR and result",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"backup", "result"})
+
+	backupSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "backup_size_bytes",
+		Help:      "Size of the backup archives produced, per Backup CR",
+		Buckets:   prometheus.ExponentialBuckets(1024, 4, 12),
+	}, []string{"backup"})
+
+	backupObjectsGathered = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backup_objects_gathered",
+		Help:      "Number of objects gathered by the latest successful backup, per Backup CR and resource",
+	}, []string{"backup", "resource"})
+
+	backupLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backup_last_success_timestamp_seconds",
+		Help:      "Unix timestamp of the latest successful backup, per Backup CR",
+	}, []string{"backup"})
+
+	backupVerifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "backup_verifications_total",
+		Help:      "Number of backup files read back to verify them after upload or while scrubbing, per Backup CR and result",
+	}, []string{"backup", "result"})
+
+	restoresStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "restores_started_total",
+		Help:      "Number of restores started, per Restore CR",
+	}, []string{"restore"})
+
+	restoresCompleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "restores_completed_total",
+		Help:      "Number of restores completed, per Restore CR and result",
+	}, []string{"restore", "result"})
+
+	restoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "restore_duration_seconds",
+		Help:      "Duration of successful restores, per Restore CR",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+	}, []string{"restore"})
+
+	restoreObjectsRestored = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "restore_objects_restored_total",
+		Help:      "Number of objects created or updated by restores, per resource",
+	}, []string{"resource"})
+
+	restorePruneDeletions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "restore_prune_deletions_total",
+		Help:      "Number of objects deleted while pruning during restores",
+	})
+
+	s3Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "s3_retries_total",
+		Help:      "Number of retried S3 operations, per operation",
+	}, []string{"operation"})
+
+	// gatheredResourcesMu guards gatheredResources, the resources of the objects gathered series of each Backup CR, which are
+	// deleted along with it
+	gatheredResourcesMu sync.Mutex
+	gatheredResources   = make(map[string]map[string]bool)
+)
+
+func init() {
+	prometheus.MustRegister(
+		backupsStarted,
+		backupsCompleted,
+		backupDuration,
+		backupSize,
+		backupObjectsGathered,
+		backupLastSuccess,
+		backupVerifications,
+		restoresStarted,
+		restoresCompleted,
+		restoreDuration,
+		restoreObjectsRestored,
+		restorePruneDeletions,
+		s3Retries,
+	)
+}
+
+// Serve exposes the registered metrics at /metrics on the given address. It doesn't return
+func Serve(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	logrus.Infof("Serving metrics on %v/metrics", address)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		logrus.Errorf("Error serving metrics: %v", err)
+	}
+}
+
+// BackupRemoved drops the series of a deleted Backup CR, so that they don't pile up and alerts on stale backups don't fire for it
+func BackupRemoved(backupName string) {
+	backupsStarted.DeleteLabelValues(backupName)
+	backupSize.DeleteLabelValues(backupName)
+	backupLastSuccess.DeleteLabelValues(backupName)
+	for _, result := range []string{ResultSucceeded, ResultFailed} {
+		backupsCompleted.DeleteLabelValues(backupName, result)
+		backupDuration.DeleteLabelValues(backupName, result)
+		backupVerifications.DeleteLabelValues(backupName, result)
+	}
+	gatheredResourcesMu.Lock()
+	defer gatheredResourcesMu.Unlock()
+	for resource := range gatheredResources[backupName] {
+		backupObjectsGathered.DeleteLabelValues(backupName, resource)
+	}
+	delete(gatheredResources, backupName)
+}
+
+func BackupStarted(backupName string) {
+	backupsStarted.WithLabelValues(backupName).Inc()
+}
+
+func BackupSucceeded(backupName string, duration time.Duration, size int64, objectCounts map[string]int) {
+	backupsCompleted.WithLabelValues(backupName, ResultSucceeded).Inc()
+	backupDuration.WithLabelValues(backupName, ResultSucceeded).Observe(duration.Seconds())
+	backupSize.WithLabelValues(backupName).Observe(float64(size))
+	gatheredResourcesMu.Lock()
+	// resources the latest backup didn't gather any object of no longer have a count
+	for resource := range gatheredResources[backupName] {
+		if _, ok := objectCounts[resource]; !ok {
+			backupObjectsGathered.DeleteLabelValues(backupName, resource)
+		}
+	}
+	resources := make(map[string]bool)
+	for resource, count := range objectCounts {
+		backupObjectsGathered.WithLabelValues(backupName, resource).Set(float64(count))
+		resources[resource] = true
+	}
+	gatheredResources[backupName] = resources
+	gatheredResourcesMu.Unlock()
+	backupLastSuccess.WithLabelValues(backupName).Set(float64(time.Now().Unix()))
+}
+
+func BackupFailed(backupName string, duration time.Duration) {
+	backupsCompleted.WithLabelValues(backupName, ResultFailed).Inc()
+	backupDuration.WithLabelValues(backupName, ResultFailed).Observe(duration.Seconds())
+}
+
+func BackupVerified(backupName string, succeeded bool) {
+	result := ResultSucceeded
+	if !succeeded {
+		result = ResultFailed
+	}
+	backupVerifications.WithLabelValues(backupName, result).Inc()
+}
+
+func RestoreStarted(restoreName string) {
+	restoresStarted.WithLabelValues(restoreName).Inc()
+}
+
+func RestoreSucceeded(restoreName string, duration time.Duration) {
+	restoresCompleted.WithLabelValues(restoreName, ResultSucceeded).Inc()
+	restoreDuration.WithLabelValues(restoreName).Observe(duration.Seconds())
+}
+
+func RestoreFailed(restoreName string) {
+	restoresCompleted.WithLabelValues(restoreName, ResultFailed).Inc()
+}
+
+func ObjectRestored(gvr schema.GroupVersionResource) {
+	restoreObjectsRestored.WithLabelValues(ResourceLabel(gvr.GroupVersion(), gvr.Resource)).Inc()
+}
+
+func ObjectPruned() {
+	restorePruneDeletions.Inc()
+}
+
+func S3Retried(operation string) {
+	s3Retries.WithLabelValues(operation).Inc()
+}
+
+// ResourceLabel formats a resource as group/version/resource, for example apps/v1/deployments or v1/secrets
+func ResourceLabel(gv schema.GroupVersion, resource string) string {
+	return fmt.Sprintf("%s/%s", gv.String(), resource)
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/minio/minio-go/v6"
 	"github.com/minio/minio-go/v6/pkg/credentials"
 	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/rancher/backup-restore-operator/pkg/metrics"
+	"github.com/rancher/backup-restore-operator/pkg/util"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -72,6 +74,7 @@ func SetS3Service(bc *v1.S3ObjectStore, accessKey, secretKey string, useSSL bool
 			if retries >= s3ServerRetries {
 				return nil, fmt.Errorf("failed to set s3 server: %v", err)
 			}
+			metrics.S3Retried("init")
 			continue
 		}
 		if bc.EndpointCA != "" {
@@ -144,16 +147,30 @@ func getBucketLookupType(endpoint string) minio.BucketLookupType {
 	return minio.BucketLookupAuto
 }
 
-func UploadBackupFile(svc *minio.Client, bucketName, fileName, filePath string) error {
+// UploadBackupFile uploads the backup file, with the user metadata and object tags describing it
+func UploadBackupFile(ctx context.Context, svc *minio.Client, bucketName, fileName, filePath string, userMetadata, tags map[string]string) error {
 	// Upload the zip file with FPutObject
 	log.Infof("invoking uploading backup file [%s] to s3", fileName)
+	opts := minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: userMetadata,
+		UserTags:     tags,
+	}
 	for retries := 0; retries <= s3ServerRetries; retries++ {
-		n, err := svc.FPutObject(bucketName, fileName, filePath, minio.PutObjectOptions{ContentType: contentType})
+		n, err := svc.FPutObjectWithContext(ctx, bucketName, fileName, filePath, opts)
+		if err != nil && ctx.Err() != nil {
+			// the upload was cancelled, don't leave the parts uploaded so far behind in the bucket
+			if removeErr := svc.RemoveIncompleteUpload(bucketName, fileName); removeErr != nil {
+				log.Errorf("failed to remove incomplete upload of backup file [%s]: %v", fileName, removeErr)
+			}
+			return fmt.Errorf("failed to upload backup file: %v", ctx.Err())
+		}
 		if err != nil {
 			log.Infof("failed to upload backup file: %v, retried %d times", err, retries)
 			if retries >= s3ServerRetries {
 				return fmt.Errorf("failed to upload backup file: %v", err)
 			}
+			metrics.S3Retried("upload")
 			continue
 		}
 		log.Infof("Successfully uploaded [%s] of size [%d]", fileName, n)
@@ -162,6 +179,15 @@ func UploadBackupFile(svc *minio.Client, bucketName, fileName, filePath string)
 	return nil
 }
 
+// GetObjectTags returns the tags of the object, S3 returns none for objects uploaded before backup files were tagged
+func GetObjectTags(client *minio.Client, bucketName, objectName string) (map[string]string, error) {
+	tagging, err := client.GetObjectTagging(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	return util.ParseObjectTagging(tagging)
+}
+
 func DownloadFromS3WithPrefix(client *minio.Client, prefix, bucket string) (string, error) {
 	var filename string
 	doneCh := make(chan struct{})
@@ -195,6 +221,7 @@ func DownloadFromS3WithPrefix(client *minio.Client, prefix, bucket string) (stri
 			if retries >= s3ServerRetries {
 				return "", fmt.Errorf("unable to download backup file for [%s]: %v", filename, err)
 			}
+			metrics.S3Retried("download")
 		}
 		if err == nil {
 			log.Infof("Successfully downloaded [%s]", filename)
@@ -34,6 +34,10 @@ type ResourceHandler struct {
 	DynamicClient       dynamic.Interface
 	TransformerMap      map[schema.GroupResource]value.Transformer
 	GVResourceToObjects map[GVResource][]unstructured.Unstructured
+	// Namespace confines gathering to namespaced resources in this namespace, if set
+	Namespace string
+
+	permissionErrors PermissionErrors
 }
 
 /*  GatherResources iterates over the ResourceSelectors in the given ResourceSet
@@ -54,6 +58,7 @@ type ResourceHandler struct {
 func (h *ResourceHandler) GatherResources(ctx context.Context, resourceSelectors []v1.ResourceSelector) (map[string]bool, error) {
 	resourcesWithStatusSubresource := make(map[string]bool)
 	h.GVResourceToObjects = make(map[GVResource][]unstructured.Unstructured)
+	h.permissionErrors = PermissionErrors{}
 
 	for _, resourceSelector := range resourceSelectors {
 		resourceList, err := h.gatherResourcesForGroupVersion(resourceSelector)
@@ -80,6 +85,9 @@ func (h *ResourceHandler) GatherResources(ctx context.Context, resourceSelectors
 				// no need to save contents of any subresource as they are a part of the resource
 				continue
 			}
+			if h.Namespace != "" && !res.Namespaced {
+				continue
+			}
 
 			if !canListResource(res.Verbs) {
 				if canGetResource(res.Verbs) {
@@ -108,6 +116,10 @@ func (h *ResourceHandler) GatherResources(ctx context.Context, resourceSelectors
 			}
 		}
 	}
+	if h.permissionErrors.Len() > 0 {
+		// gather everything else first, so that all missing permissions are reported together
+		return resourcesWithStatusSubresource, &h.permissionErrors
+	}
 	return resourcesWithStatusSubresource, nil
 }
 
@@ -189,10 +201,17 @@ func (h *ResourceHandler) gatherObjectsForResource(ctx context.Context, res k8sv
 	gvr := gv.WithResource(res.Name)
 	var dr dynamic.ResourceInterface
 	dr = h.DynamicClient.Resource(gvr)
+	if h.Namespace != "" {
+		dr = h.DynamicClient.Resource(gvr).Namespace(h.Namespace)
+	}
 
 	// only resources that match name+namespace+label combination will be backed up, so we can filter in any order
 	filteredByName, err := h.filterByNameAndLabel(ctx, dr, filter)
 	if err != nil {
+		if apierrors.IsForbidden(err) {
+			h.permissionErrors.Add(gvr, err)
+			return filteredObjects, nil
+		}
 		return filteredObjects, err
 	}
 
@@ -396,9 +415,16 @@ func (h *ResourceHandler) gatherObjectsForNonListResource(ctx context.Context, r
 	dr = h.DynamicClient.Resource(gvr)
 	if res.Namespaced {
 		for _, ns := range filter.Namespaces {
+			if h.Namespace != "" && ns != h.Namespace {
+				continue
+			}
 			dr = h.DynamicClient.Resource(gvr).Namespace(ns)
 			for _, name := range filter.ResourceNames {
 				obj, err := dr.Get(ctx, name, k8sv1.GetOptions{})
+				if apierrors.IsForbidden(err) {
+					h.permissionErrors.Add(gvr, err)
+					continue
+				}
 				if err != nil {
 					return gatheredObjects, err
 				}
@@ -410,6 +436,10 @@ func (h *ResourceHandler) gatherObjectsForNonListResource(ctx context.Context, r
 
 	for _, name := range filter.ResourceNames {
 		obj, err := dr.Get(ctx, name, k8sv1.GetOptions{})
+		if apierrors.IsForbidden(err) {
+			h.permissionErrors.Add(gvr, err)
+			continue
+		}
 		if err != nil {
 			return gatheredObjects, err
 		}
@@ -0,0 +1,67 @@
+package resourcesets
+
+import (
+	"regexp"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MatchesSelector returns true if the object of the given resource would have been gathered with the ResourceSelector, following
+// the same rules as GatherResources: kinds, names and namespaces each match by either their list or their regexp, and all of
+// them and the label selector have to match. Kinds are compared with both the resource name and the object's kind
+func MatchesSelector(selector v1.ResourceSelector, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (bool, error) {
+	if selector.APIVersion != gvr.GroupVersion().String() {
+		return false, nil
+	}
+	if selector.KindsRegexp != "" || len(selector.Kinds) > 0 {
+		matched, err := matchesListOrRegexp(selector.Kinds, selector.KindsRegexp, gvr.Resource, obj.GetKind())
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if selector.ResourceNameRegexp != "" || len(selector.ResourceNames) > 0 {
+		matched, err := matchesListOrRegexp(selector.ResourceNames, selector.ResourceNameRegexp, obj.GetName())
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if obj.GetNamespace() != "" && (selector.NamespaceRegexp != "" || len(selector.Namespaces) > 0) {
+		matched, err := matchesListOrRegexp(selector.Namespaces, selector.NamespaceRegexp, obj.GetNamespace())
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if selector.LabelSelectors != nil {
+		labelSelector, err := k8sv1.LabelSelectorAsSelector(selector.LabelSelectors)
+		if err != nil {
+			return false, err
+		}
+		if !labelSelector.Matches(labels.Set(obj.GetLabels())) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchesListOrRegexp returns true if any of the values is in the list or matches the regexp
+func matchesListOrRegexp(list []string, expr string, values ...string) (bool, error) {
+	for _, value := range values {
+		for _, item := range list {
+			if item == value {
+				return true, nil
+			}
+		}
+		if expr == "" {
+			continue
+		}
+		matched, err := regexp.MatchString(expr, value)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
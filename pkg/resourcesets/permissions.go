@@ -0,0 +1,45 @@
+package resourcesets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PermissionErrors collects the errors of requests the client wasn't allowed to make, keeping the first one per resource type,
+// so every resource type missing permissions can be reported at once instead of only the first one encountered
+type PermissionErrors struct {
+	mu        sync.Mutex
+	forbidden map[schema.GroupVersionResource]string
+}
+
+func (p *PermissionErrors) Add(gvr schema.GroupVersionResource, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.forbidden == nil {
+		p.forbidden = make(map[schema.GroupVersionResource]string)
+	}
+	if _, ok := p.forbidden[gvr]; !ok {
+		p.forbidden[gvr] = err.Error()
+	}
+}
+
+func (p *PermissionErrors) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.forbidden)
+}
+
+func (p *PermissionErrors) Error() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var forbidden []string
+	for gvr, msg := range p.forbidden {
+		forbidden = append(forbidden, fmt.Sprintf("[%v]: %v", gvr.String(), msg))
+	}
+	sort.Strings(forbidden)
+	return fmt.Sprintf("missing permissions for %v resource types: %v", len(forbidden), strings.Join(forbidden, "; "))
+}
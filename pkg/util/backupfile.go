@@ -0,0 +1,164 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	v1 "github.com/rancher/backup-restore-operator/pkg/apis/resources.cattle.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/storage/value"
+)
+
+// BackupFileFilters holds the files of a backup file's filters directory
+type BackupFileFilters struct {
+	// ResourceSet is the ResourceSet the backup was taken with
+	ResourceSet v1.ResourceSet
+	// ResourcesWithStatusSubresource are the GVRs of the backed up resources having a status subresource
+	ResourcesWithStatusSubresource map[string]bool
+}
+
+// BackupFileObject is an object read from a backup file, decrypted if its resource was encrypted
+type BackupFileObject struct {
+	// Path is the path of the object's file within the tarball, such as serviceaccounts.#v1/cattle-system/cattle.json or
+	// users.management.cattle.io#v3/u-lqx8j.json
+	Path      string
+	GVR       schema.GroupVersionResource
+	Namespace string
+	Name      string
+	// ModTime is when the backup wrote the object's file
+	ModTime time.Time
+	Object  map[string]interface{}
+}
+
+// ReadBackupFile reads a backup file the way restores do, decompressing the tarball and decrypting and parsing every object in it,
+// which is passed to readObject. Backups verify their files with it too, so a backup file that verifies can be restored
+func ReadBackupFile(tarGzFilePath string, transformerMap map[schema.GroupResource]value.Transformer,
+	readObject func(object BackupFileObject) error) (*BackupFileFilters, error) {
+	// very initial parts: https://medium.com/@skdomino/taring-untaring-files-in-go-6b07cf56bc07
+	r, err := os.Open(tarGzFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening tarball backup file %v", err)
+	}
+	defer r.Close()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tarball := tar.NewReader(gz)
+
+	filters := &BackupFileFilters{ResourcesWithStatusSubresource: make(map[string]bool)}
+	for {
+		tarContent, err := tarball.Next()
+		if err == io.EOF {
+			return filters, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tarContent.Typeflag != tar.TypeReg {
+			continue
+		}
+		readData, err := ioutil.ReadAll(tarball)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(tarContent.Name, "filters") {
+			if strings.Contains(tarContent.Name, "filters.json") {
+				if err := json.Unmarshal(readData, &filters.ResourceSet); err != nil {
+					return nil, fmt.Errorf("error unmarshaling backup filters file: %v", err)
+				}
+			}
+			if strings.Contains(tarContent.Name, "statussubresource.json") {
+				if err := json.Unmarshal(readData, &filters.ResourcesWithStatusSubresource); err != nil {
+					return nil, fmt.Errorf("error unmarshaling status subresource info file: %v", err)
+				}
+			}
+			continue
+		}
+
+		object, err := readObjectFile(tarContent, readData, transformerMap)
+		if err != nil {
+			return nil, err
+		}
+		if err := readObject(object); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// VerifyBackupFile reads the backup file the way a restore does, without restoring anything, and returns the number of objects in it
+func VerifyBackupFile(tarGzFilePath string, transformerMap map[schema.GroupResource]value.Transformer) (int, error) {
+	objects := 0
+	_, err := ReadBackupFile(tarGzFilePath, transformerMap, func(BackupFileObject) error {
+		objects++
+		return nil
+	})
+	return objects, err
+}
+
+func readObjectFile(tarContent *tar.Header, readData []byte, transformerMap map[schema.GroupResource]value.Transformer) (BackupFileObject, error) {
+	object := BackupFileObject{Path: tarContent.Name, ModTime: tarContent.ModTime}
+	var additionalAuthenticatedData string
+
+	splitPath := strings.Split(tarContent.Name, "/")
+	if len(splitPath) == 2 {
+		// cluster scoped resource, since no subdir for namespace
+		object.Name = strings.TrimSuffix(splitPath[1], ".json")
+		additionalAuthenticatedData = object.Name
+	} else {
+		// namespaced resource, splitPath[0] =  serviceaccounts.#v1, splitPath[1] = namespace
+		object.Name = strings.TrimSuffix(splitPath[2], ".json")
+		object.Namespace = splitPath[1]
+		additionalAuthenticatedData = fmt.Sprintf("%s#%s", object.Namespace, object.Name)
+	}
+	gvr := getGVR(splitPath[0])
+	object.GVR = gvr
+
+	decryptionTransformer := transformerMap[gvr.GroupResource()]
+	if decryptionTransformer != nil {
+		var encryptedBytes []byte
+		if err := json.Unmarshal(readData, &encryptedBytes); err != nil {
+			logrus.Errorf("Error unmarshaling encrypted data for resource [%v]: %v", gvr.GroupResource(), err)
+			return object, fmt.Errorf("error unmarshaling encrypted data for resource [%v]: %v", gvr.GroupResource(), err)
+		}
+		decrypted, _, err := decryptionTransformer.TransformFromStorage(encryptedBytes, value.DefaultContext(additionalAuthenticatedData))
+		if err != nil {
+			logrus.Errorf("Error decrypting encrypted resource [%v]: %v, provide same encryption config as used for backup", gvr.GroupResource(), err)
+			return object, fmt.Errorf("error decrypting encrypted resource [%v]: %v, provide same encryption config as used for backup", gvr.GroupResource(), err)
+		}
+		readData = decrypted
+	}
+	if err := json.Unmarshal(readData, &object.Object); err != nil {
+		if strings.Contains(err.Error(), "json: cannot unmarshal string into Go value") && decryptionTransformer == nil {
+			// This will be the case if we try to unmarshal an encrypted resource without decrypting it first
+			logrus.Errorf("Error unmarshaling encryped resource [%v], no encryption config provided ", gvr.GroupResource())
+			return object, fmt.Errorf("error unmarshaling encryped resource [%v], no encryption config provided", gvr.GroupResource())
+		}
+		return object, err
+	}
+	return object, nil
+}
+
+// getGVR parses the directory path to provide groupVersionResource
+func getGVR(resourceGVR string) schema.GroupVersionResource {
+	gvkParts := strings.Split(resourceGVR, "#")
+	version := gvkParts[1]
+	resourceGroup := strings.SplitN(gvkParts[0], ".", 2)
+	resource := strings.TrimSuffix(resourceGroup[0], ".")
+	var group string
+	if len(resourceGroup) > 1 {
+		group = resourceGroup[1]
+	}
+	gr := schema.ParseGroupResource(resource + "." + group)
+	gvr := gr.WithVersion(version)
+	return gvr
+}
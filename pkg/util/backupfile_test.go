@@ -0,0 +1,159 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "backupfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeBackupFile(t *testing.T, dir string, files map[string]string) string {
+	path := filepath.Join(dir, "backup.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadBackupFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeBackupFile(t, dir, map[string]string{
+		"filters/filters.json":                          `{"resourceSelectors":[{"apiVersion":"v1"}]}`,
+		"filters/statussubresource.json":                `{"apps/v1, Resource=deployments":true}`,
+		"serviceaccounts.#v1/cattle-system/cattle.json": `{"kind":"ServiceAccount"}`,
+		"users.management.cattle.io#v3/u-lqx8j.json":    `{"kind":"User"}`,
+	})
+	objects := make(map[string]BackupFileObject)
+	filters, err := ReadBackupFile(path, nil, func(object BackupFileObject) error {
+		objects[object.Path] = object
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadBackupFile() error = %v", err)
+	}
+	if len(filters.ResourceSet.ResourceSelectors) != 1 || filters.ResourceSet.ResourceSelectors[0].APIVersion != "v1" {
+		t.Errorf("ReadBackupFile() read resource selectors %v", filters.ResourceSet.ResourceSelectors)
+	}
+	if want := map[string]bool{"apps/v1, Resource=deployments": true}; !reflect.DeepEqual(filters.ResourcesWithStatusSubresource, want) {
+		t.Errorf("ReadBackupFile() read status subresources %v, want %v", filters.ResourcesWithStatusSubresource, want)
+	}
+
+	tests := []struct {
+		path string
+		want BackupFileObject
+	}{
+		{
+			path: "serviceaccounts.#v1/cattle-system/cattle.json",
+			want: BackupFileObject{
+				GVR:       schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"},
+				Namespace: "cattle-system",
+				Name:      "cattle",
+				Object:    map[string]interface{}{"kind": "ServiceAccount"},
+			},
+		},
+		{
+			path: "users.management.cattle.io#v3/u-lqx8j.json",
+			want: BackupFileObject{
+				GVR:    schema.GroupVersionResource{Group: "management.cattle.io", Version: "v3", Resource: "users"},
+				Name:   "u-lqx8j",
+				Object: map[string]interface{}{"kind": "User"},
+			},
+		},
+	}
+	if len(objects) != len(tests) {
+		t.Errorf("ReadBackupFile() read %v objects, want %v", len(objects), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := objects[tt.path]
+			got.ModTime = tt.want.ModTime
+			tt.want.Path = tt.path
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadBackupFile() read %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBackupFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantObjects int
+		wantErr     bool
+	}{
+		{
+			name: "objects are counted",
+			files: map[string]string{
+				"filters/filters.json":                          `{}`,
+				"serviceaccounts.#v1/cattle-system/cattle.json": `{"kind":"ServiceAccount"}`,
+				"namespaces.#v1/cattle-system.json":             `{"kind":"Namespace"}`,
+			},
+			wantObjects: 2,
+		},
+		{
+			name:    "invalid object",
+			files:   map[string]string{"namespaces.#v1/cattle-system.json": `{"kind":`},
+			wantErr: true,
+		},
+		{
+			name:    "encrypted object without encryption config",
+			files:   map[string]string{"secrets.#v1/cattle-system/token.json": `"ZW5jcnlwdGVk"`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid filters",
+			files:   map[string]string{"filters/filters.json": `[]`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+			objects, err := VerifyBackupFile(writeBackupFile(t, dir, tt.files), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyBackupFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && objects != tt.wantObjects {
+				t.Errorf("VerifyBackupFile() = %v, want %v", objects, tt.wantObjects)
+			}
+		})
+	}
+
+	if _, err := VerifyBackupFile(filepath.Join(os.TempDir(), "missing.tar.gz"), nil); err == nil {
+		t.Errorf("VerifyBackupFile() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/client-go/rest"
+)
+
+// ServiceAccountConfig returns a copy of the config impersonating the service account, so that its RBAC permissions limit what
+// can be read and written with it. The service account is looked up in the given namespace, or in the chart's namespace if it's empty
+func ServiceAccountConfig(config *rest.Config, namespace, serviceAccountName string) *rest.Config {
+	if namespace == "" {
+		namespace = ChartNamespace
+	}
+	impersonating := rest.CopyConfig(config)
+	impersonating.Impersonate = rest.ImpersonationConfig{
+		UserName: serviceaccount.MakeUsername(namespace, serviceAccountName),
+	}
+	return impersonating
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// RunAsLeader blocks until this replica acquires the named Lease in the chart's namespace, and then calls run. Replicas that
+// don't hold the Lease stay on hot-standby. Losing the Lease exits the process, so that a former leader can't keep running
+// backups and restores alongside the new one
+func RunAsLeader(ctx context.Context, client kubernetes.Interface, name string, run func(ctx context.Context)) error {
+	identity, err := leaderIdentity()
+	if err != nil {
+		return err
+	}
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock,
+		ChartNamespace,
+		name,
+		client.CoreV1(),
+		client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity: identity,
+		})
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Waiting to acquire leader election lease %v/%v as %v", ChartNamespace, name, identity)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logrus.Infof("Acquired leader election lease %v/%v as %v", ChartNamespace, name, identity)
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					// shutting down, the lease was released for the next leader
+					return
+				}
+				logrus.Fatalf("Lost leader election lease %v/%v", ChartNamespace, name)
+			},
+		},
+		ReleaseOnCancel: true,
+		Name:            name,
+	})
+	return nil
+}
+
+// leaderIdentity identifies this replica in the Lease, the pod name is preferred since it is what users see in kubectl
+func leaderIdentity() (string, error) {
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName, nil
+	}
+	return os.Hostname()
+}
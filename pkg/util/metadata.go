@@ -0,0 +1,164 @@
+package util
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MetadataFileSuffix is appended to the path of a backup file for the metadata file stored next to it
+const MetadataFileSuffix = ".metadata.json"
+
+// Keys of the object tags attached to backup files uploaded to S3. The labels copied from the Backup CR keep their own keys
+const (
+	ObjectTagPrefix           = "resources.cattle.io/"
+	ObjectTagBackupName       = ObjectTagPrefix + "backup-name"
+	ObjectTagClusterUID       = ObjectTagPrefix + "cluster-uid"
+	ObjectTagClusterName      = ObjectTagPrefix + "cluster-name"
+	ObjectTagResourceSet      = ObjectTagPrefix + "resource-set"
+	ObjectTagOperatorVersion  = ObjectTagPrefix + "operator-version"
+	ObjectTagEncryptionConfig = ObjectTagPrefix + "encryption-config"
+	ObjectTagCreatedAt        = ObjectTagPrefix + "created-at"
+	ObjectTagEncrypted        = ObjectTagPrefix + "encrypted"
+	ObjectTagDeletionPolicy   = ObjectTagPrefix + "deletion-policy"
+
+	// MaxObjectTags is the number of tags S3 allows on an object
+	MaxObjectTags = 10
+	// fixedObjectTags is the number of tags the operator sets on every backup file
+	fixedObjectTags = 9
+	// MaxObjectTagLabels is the number of the Backup CR's labels that can be copied into the tags of its backup files
+	MaxObjectTagLabels = MaxObjectTags - fixedObjectTags
+)
+
+var (
+	// ClusterName is included in backup filenames by the filename template's ClusterName placeholder
+	ClusterName string
+	// OperatorVersion is recorded in the tags and user metadata of backup files uploaded to S3
+	OperatorVersion string
+)
+
+// BackupMetadata describes a backup file, so it can be attributed to its Backup CR without relying on the shape of its filename
+type BackupMetadata struct {
+	BackupName  string `json:"backupName"`
+	ClusterUID  string `json:"clusterUID"`
+	ClusterName string `json:"clusterName,omitempty"`
+	// Filename is the path of the backup file relative to its storage location
+	Filename                   string `json:"filename"`
+	CreatedAt                  string `json:"createdAt"`
+	Encrypted                  bool   `json:"encrypted"`
+	ResourceSetName            string `json:"resourceSetName,omitempty"`
+	OperatorVersion            string `json:"operatorVersion,omitempty"`
+	EncryptionConfigSecretName string `json:"encryptionConfigSecretName,omitempty"`
+	// DeletionPolicy is the deletionPolicy of the Backup CR when the file was taken, only files recorded as Delete are deleted
+	// once their Backup CR is gone
+	DeletionPolicy string            `json:"deletionPolicy,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+func MetadataFilename(backupFilename string) string {
+	return backupFilename + MetadataFileSuffix
+}
+
+func IsMetadataFilename(filename string) bool {
+	return strings.HasSuffix(filename, MetadataFileSuffix)
+}
+
+func ParseBackupMetadata(data []byte) (*BackupMetadata, error) {
+	metadata := &BackupMetadata{}
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, fmt.Errorf("error parsing backup metadata: %v", err)
+	}
+	return metadata, nil
+}
+
+// ObjectTags returns the S3 object tags describing the backup file, the fixed set followed by the labels copied from the Backup CR
+func (m *BackupMetadata) ObjectTags() map[string]string {
+	tags := map[string]string{
+		ObjectTagBackupName:       m.BackupName,
+		ObjectTagClusterUID:       m.ClusterUID,
+		ObjectTagClusterName:      m.ClusterName,
+		ObjectTagResourceSet:      m.ResourceSetName,
+		ObjectTagOperatorVersion:  m.OperatorVersion,
+		ObjectTagEncryptionConfig: m.EncryptionConfigSecretName,
+		ObjectTagCreatedAt:        m.CreatedAt,
+		ObjectTagEncrypted:        strconv.FormatBool(m.Encrypted),
+		ObjectTagDeletionPolicy:   m.DeletionPolicy,
+	}
+	for key, value := range m.Labels {
+		tags[key] = value
+	}
+	return tags
+}
+
+// UserMetadata returns the same information as ObjectTags as S3 user metadata. Unlike tags, user metadata is sent as HTTP headers,
+// so the "/" in the keys is replaced
+func (m *BackupMetadata) UserMetadata() map[string]string {
+	userMetadata := make(map[string]string)
+	for key, value := range m.ObjectTags() {
+		key = strings.TrimPrefix(key, ObjectTagPrefix)
+		userMetadata[strings.Replace(key, "/", "_", -1)] = value
+	}
+	return userMetadata
+}
+
+// MatchesTags returns true if the backup file has all the given tags
+func (m *BackupMetadata) MatchesTags(selector map[string]string) bool {
+	tags := m.ObjectTags()
+	for key, value := range selector {
+		if tagValue, ok := tags[key]; !ok || tagValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// BackupMetadataFromTags returns the metadata of a backup file recorded in its object tags, or nil if it wasn't tagged by the operator
+func BackupMetadataFromTags(filename string, tags map[string]string) *BackupMetadata {
+	if tags[ObjectTagBackupName] == "" || tags[ObjectTagClusterUID] == "" {
+		return nil
+	}
+	metadata := &BackupMetadata{
+		BackupName:                 tags[ObjectTagBackupName],
+		ClusterUID:                 tags[ObjectTagClusterUID],
+		ClusterName:                tags[ObjectTagClusterName],
+		Filename:                   filename,
+		CreatedAt:                  tags[ObjectTagCreatedAt],
+		Encrypted:                  tags[ObjectTagEncrypted] == "true",
+		ResourceSetName:            tags[ObjectTagResourceSet],
+		OperatorVersion:            tags[ObjectTagOperatorVersion],
+		EncryptionConfigSecretName: tags[ObjectTagEncryptionConfig],
+		DeletionPolicy:             tags[ObjectTagDeletionPolicy],
+	}
+	for key, value := range tags {
+		if strings.HasPrefix(key, ObjectTagPrefix) {
+			continue
+		}
+		if metadata.Labels == nil {
+			metadata.Labels = make(map[string]string)
+		}
+		metadata.Labels[key] = value
+	}
+	return metadata
+}
+
+// ParseObjectTagging parses the tagging document returned by S3 for an object
+func ParseObjectTagging(data string) (map[string]string, error) {
+	var tagging struct {
+		TagSet struct {
+			Tags []struct {
+				Key   string `xml:"Key"`
+				Value string `xml:"Value"`
+			} `xml:"Tag"`
+		} `xml:"TagSet"`
+	}
+	if err := xml.Unmarshal([]byte(data), &tagging); err != nil {
+		return nil, fmt.Errorf("error parsing object tags: %v", err)
+	}
+	tags := make(map[string]string)
+	for _, tag := range tagging.TagSet.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, nil
+}
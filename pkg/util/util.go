@@ -22,12 +22,16 @@ const (
 
 var ChartNamespace string
 
-func GetEncryptionTransformers(encryptionConfigSecretName string, secrets v1core.SecretController) (map[schema.GroupResource]value.Transformer, error) {
+// GetEncryptionTransformers reads the encryption config from the given namespace, or from the chart's namespace if it's empty
+func GetEncryptionTransformers(encryptionConfigSecretName, namespace string, secrets v1core.SecretController) (map[schema.GroupResource]value.Transformer, error) {
 	var transformerMap map[schema.GroupResource]value.Transformer
-	// EncryptionConfig secret ns is hardcoded to ns of controller in chart's ns
+	// EncryptionConfig secret ns defaults to ns of controller in chart's ns, backups and restores restricted to a namespace read it from there
 	// kubectl create secret generic test-encryptionconfig --from-file=./encryption-provider-config.yaml
-	logrus.Infof("Get encryption config from namespace %v", ChartNamespace)
-	encryptionConfigSecret, err := secrets.Get(ChartNamespace, encryptionConfigSecretName, k8sv1.GetOptions{})
+	if namespace == "" {
+		namespace = ChartNamespace
+	}
+	logrus.Infof("Get encryption config from namespace %v", namespace)
+	encryptionConfigSecret, err := secrets.Get(namespace, encryptionConfigSecretName, k8sv1.GetOptions{})
 	if err != nil {
 		return transformerMap, err
 	}